@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+// Assistant ищет ответ на вопрос оператора: сначала точное совпадение в базе, затем
+// похожие записи и, если их нет или ни одна не подошла, генерация через Ollama по найденным записям.
+// Не зависит от окна, поэтому используется и интерфейсом, и командой ask
+type Assistant struct {
+	faq      *FAQService
+	feedback *FeedbackStore
+	searcher *Searcher
+	rag      *RAGBuilder
+	ollama   *OllamaClient
+	cfg      SearchConfig
+}
+
+// NewAssistant создает поиск ответов поверх базы, поиска и модели
+func NewAssistant(faq *FAQService, feedback *FeedbackStore, searcher *Searcher, rag *RAGBuilder, ollama *OllamaClient, cfg SearchConfig) *Assistant {
+	return &Assistant{
+		faq:      faq,
+		feedback: feedback,
+		searcher: searcher,
+		rag:      rag,
+		ollama:   ollama,
+		cfg:      cfg,
+	}
+}
+
+// Lookup — то, что нашлось в базе по вопросу
+type Lookup struct {
+	Exact *FAQEntry   // запись с дословно совпавшим вопросом; nil — совпадения нет
+	Hits  []SearchHit // похожие записи по убыванию релевантности
+}
+
+// Lookup ищет ответ в базе. Hits нужны и оператору, и как контекст модели,
+// поэтому их может быть больше cfg.MaxResults: показывать стоит только первые.
+// К модели обращаются, только если похожих записей нет или ни одна не подошла оператору
+func (a *Assistant) Lookup(ctx context.Context, question string, filter SearchFilter) (Lookup, error) {
+	// Точное совпадение, которым операторы недовольны, проходит обычный поиск и уступает лучшим записям.
+	// Совпадение из другой категории фильтр отсекает так же, как результаты поиска
+	if entry, ok := a.faq.FindExact(question); ok && filter.match(entry) && !a.feedback.Demoted(entry.ID) {
+		return Lookup{Exact: &entry}, nil
+	}
+
+	hits, err := a.searcher.Search(ctx, question, max(a.cfg.MaxResults, a.rag.TopK()), filter)
+	if err != nil {
+		return Lookup{}, err
+	}
+	return Lookup{Hits: hits}, nil
+}
+
+// ModelPrompt — запрос к модели и записи базы, попавшие в него как контекст
+type ModelPrompt struct {
+	Question string
+	Used     []FAQEntry
+	text     string
+}
+
+// Sources возвращает записи контекста в виде ссылок для истории
+func (p ModelPrompt) Sources() []Citation {
+	return citationsFor(p.Used)
+}
+
+// Prompt собирает запрос к модели из вопроса и найденных записей
+func (a *Assistant) Prompt(question string, hits []SearchHit) (ModelPrompt, error) {
+	entries := make([]FAQEntry, 0, len(hits))
+	for _, hit := range hits {
+		entries = append(entries, hit.Entry)
+	}
+	text, used, err := a.rag.buildPrompt(question, entries)
+	if err != nil {
+		return ModelPrompt{}, err
+	}
+	return ModelPrompt{Question: question, Used: used, text: text}, nil
+}
+
+// Generate генерирует ответ модели; onToken получает ответ по частям по мере поступления.
+// started — момент, когда был задан вопрос
+func (a *Assistant) Generate(ctx context.Context, prompt ModelPrompt, started time.Time, onToken func(string)) (string, Provenance, error) {
+	answer, err := a.ollama.generateAnswer(ctx, prompt.text, onToken)
+	if err != nil {
+		return "", Provenance{}, err
+	}
+	return answer, Provenance{
+		Source:  HistorySourceLLM,
+		Model:   a.ollama.Model(),
+		Options: a.ollama.OptionsJSON(),
+		Latency: time.Since(started),
+	}, nil
+}
+
+// Services — база, индекс и службы поверх них, общие для окна и команд
+type Services struct {
+	DB        *sql.DB
+	Index     bleve.Index
+	FAQ       *FAQService
+	Vectors   *VectorStore
+	Feedback  *FeedbackStore
+	Favorites *FavoritesStore
+	Searcher  *Searcher
+	Assistant *Assistant
+	Ollama    *OllamaClient
+
+	cfg Config
+}
+
+// openServices открывает базу и индекс, сверяет индекс с базой и создает службы поиска и генерации.
+// Эмбеддинги не загружаются: для векторного поиска нужно вызвать syncVectors
+func openServices(cfg Config) (*Services, error) {
+	rag, err := NewRAGBuilder(cfg.RAG)
+	if err != nil {
+		return nil, err
+	}
+	db, err := openDatabase(cfg.Paths.DB)
+	if err != nil {
+		return nil, err
+	}
+	index, err := createBleveIndex(cfg.Paths.Index)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	s := &Services{DB: db, Index: index, cfg: cfg}
+
+	s.FAQ, err = NewFAQService(db, index, cfg.Operator.Name)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	reindexed, removed, err := s.FAQ.CheckIndex()
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	if reindexed > 0 || removed > 0 {
+		log.Printf("Индекс сверен с базой: переиндексировано %d, удалено %d", reindexed, removed)
+	}
+	s.Feedback, err = NewFeedbackStore(db)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	s.Ollama = NewOllamaClient(cfg.Ollama)
+	s.Vectors = NewVectorStore(db, s.Ollama, cfg.Ollama.EmbedModel)
+	s.Favorites = NewFavoritesStore(db, s.FAQ)
+	s.Searcher = NewSearcher(index, s.Vectors, s.FAQ, s.Feedback, cfg.Search)
+	s.Assistant = NewAssistant(s.FAQ, s.Feedback, s.Searcher, rag, s.Ollama, cfg.Search)
+	return s, nil
+}
+
+// syncVectors пересчитывает эмбеддинги новых и измененных записей и загружает векторы в память.
+// В режиме bleve ничего не делает. Ошибка только записывается в журнал: без векторов поиск идет через Bleve
+func (s *Services) syncVectors(ctx context.Context) {
+	if s.cfg.Search.Mode == SearchModeBleve {
+		return
+	}
+	updated, err := s.Vectors.Sync(ctx, s.FAQ.Entries())
+	if err != nil {
+		log.Printf("Ошибка расчета эмбеддингов: %v", err)
+		return
+	}
+	if updated > 0 {
+		log.Printf("Эмбеддинги обновлены, пересчитано записей: %d", updated)
+	}
+}
+
+// Close закрывает индекс и базу
+func (s *Services) Close() {
+	s.Index.Close()
+	s.DB.Close()
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// backupTimeLayout — время снимка в имени файла (faq-20060102-150405.000.db). Миллисекунды нужны,
+// чтобы страховочный снимок при восстановлении не совпал по имени с только что созданным
+const backupTimeLayout = "20060102-150405.000"
+
+// backupPagesPerStep — сколько страниц базы копируется за шаг. Между шагами база
+// доступна другим соединениям, поэтому снимок не останавливает работу приложения
+const backupPagesPerStep = 256
+
+// Backup — снимок базы в каталоге резервных копий
+type Backup struct {
+	Path string
+	Time time.Time
+	Size int64
+}
+
+// Name возвращает имя файла снимка
+func (b Backup) Name() string {
+	return filepath.Base(b.Path)
+}
+
+// BackupService создает снимки базы по расписанию и по запросу, удаляет старые
+// и восстанавливает базу из снимка. Индекс Bleve не копируется: после восстановления
+// он строится заново из базы
+type BackupService struct {
+	db     *sql.DB
+	dbPath string
+	cfg    BackupConfig
+
+	// mu не дает снимку и восстановлению выполняться одновременно
+	mu        sync.Mutex
+	listeners []func()
+}
+
+// NewBackupService создает службу резервного копирования базы dbPath, открытой как db
+func NewBackupService(db *sql.DB, dbPath string, cfg BackupConfig) *BackupService {
+	return &BackupService{db: db, dbPath: dbPath, cfg: cfg}
+}
+
+// OnRestore подписывает функцию на восстановление базы из снимка
+func (s *BackupService) OnRestore(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// prefix — начало имени снимков: имя файла базы без расширения
+func (s *BackupService) prefix() string {
+	base := filepath.Base(s.dbPath)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + "-"
+}
+
+// List возвращает снимки, начиная с последнего
+func (s *BackupService) List() ([]Backup, error) {
+	files, err := filepath.Glob(filepath.Join(s.cfg.Dir, s.prefix()+"*.db"))
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, path := range files {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), s.prefix()), ".db")
+		t, err := time.ParseInLocation(backupTimeLayout, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, Backup{Path: path, Time: t, Size: info.Size()})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+	return backups, nil
+}
+
+// Create делает снимок базы и удаляет снимки сверх cfg.Keep
+func (s *BackupService) Create(ctx context.Context) (Backup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	backup, err := s.snapshot(ctx)
+	if err != nil {
+		return Backup{}, err
+	}
+	if _, err := s.rotate(); err != nil {
+		return backup, fmt.Errorf("снимок создан, но старые не удалены: %w", err)
+	}
+	return backup, nil
+}
+
+// snapshot копирует базу в новый файл каталога снимков. Копия пишется во временный файл
+// и переименовывается, только когда готова, поэтому в каталоге не бывает недописанных снимков
+func (s *BackupService) snapshot(ctx context.Context) (Backup, error) {
+	if err := os.MkdirAll(s.cfg.Dir, 0o755); err != nil {
+		return Backup{}, err
+	}
+	now := time.Now()
+	path := filepath.Join(s.cfg.Dir, s.prefix()+now.Format(backupTimeLayout)+".db")
+	if _, err := os.Stat(path); err == nil {
+		return Backup{}, fmt.Errorf("снимок %s уже существует", filepath.Base(path))
+	}
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+
+	dst, err := sql.Open("sqlite3", tmp)
+	if err != nil {
+		return Backup{}, err
+	}
+	err = copyDatabase(ctx, dst, s.db)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return Backup{}, fmt.Errorf("ошибка резервного копирования: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return Backup{}, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return Backup{}, err
+	}
+	return Backup{Path: path, Time: now, Size: info.Size()}, nil
+}
+
+// rotate удаляет самые старые снимки, оставляя cfg.Keep последних; 0 — хранить все
+func (s *BackupService) rotate() (int, error) {
+	if s.cfg.Keep == 0 {
+		return 0, nil
+	}
+	backups, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, backup := range backups[min(s.cfg.Keep, len(backups)):] {
+		if err := os.Remove(backup.Path); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Restore заменяет содержимое базы снимком path. Перед этим текущая база сохраняется
+// в новый снимок, чтобы восстановление можно было отменить. Снимок старой версии схемы
+// доводится до текущей миграциями; если это не удалось, база возвращается из страховочного снимка.
+// Индекс Bleve после восстановления нужно построить заново
+func (s *BackupService) Restore(ctx context.Context, path string) (Backup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	src, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return Backup{}, err
+	}
+	defer src.Close()
+	// Снимок из более новой версии программы не восстанавливаем: схема не поддерживает откат
+	if _, err := migrationStatus(src); err != nil {
+		return Backup{}, fmt.Errorf("снимок %s нельзя восстановить: %w", filepath.Base(path), err)
+	}
+
+	// Страховочный снимок не участвует в ротации, чтобы не удалить восстанавливаемый
+	safety, err := s.snapshot(ctx)
+	if err != nil {
+		return Backup{}, fmt.Errorf("не удалось сохранить текущую базу перед восстановлением: %w", err)
+	}
+
+	if err := copyDatabase(ctx, s.db, src); err != nil {
+		return safety, fmt.Errorf("ошибка восстановления из %s: %w", filepath.Base(path), err)
+	}
+	// База уже заменена: индекс, кэш FAQ и оценки перестраиваются, даже если дальше что-то не получится
+	defer func() {
+		listeners := append([]func(){}, s.listeners...)
+		for _, fn := range listeners {
+			fn()
+		}
+	}()
+
+	applied, err := runMigrations(s.db)
+	for _, m := range applied {
+		log.Printf("Применена миграция %04d_%s", m.Version, m.Name)
+	}
+	if err != nil {
+		// Снимок не удалось довести до текущей схемы — возвращаем базу, какой она была до восстановления
+		if rollbackErr := s.copyFrom(context.Background(), safety.Path); rollbackErr != nil {
+			return safety, fmt.Errorf("ошибка обновления схемы снимка %s: %w; вернуть прежнюю базу не удалось: %v",
+				filepath.Base(path), err, rollbackErr)
+		}
+		return safety, fmt.Errorf("ошибка обновления схемы снимка %s, база возвращена к прежнему состоянию: %w",
+			filepath.Base(path), err)
+	}
+	return safety, nil
+}
+
+// copyFrom заменяет содержимое базы снимком path без проверок и страховочного снимка
+func (s *BackupService) copyFrom(ctx context.Context, path string) error {
+	src, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return copyDatabase(ctx, s.db, src)
+}
+
+// Start делает снимок, если последний старше cfg.Interval, и дальше — раз в cfg.Interval
+func (s *BackupService) Start() {
+	if s.cfg.Interval == 0 {
+		return
+	}
+	go func() {
+		for {
+			wait := time.Duration(0)
+			backups, err := s.List()
+			if err != nil {
+				log.Printf("Ошибка чтения каталога резервных копий: %v", err)
+			}
+			if len(backups) > 0 {
+				wait = time.Until(backups[0].Time.Add(s.cfg.Interval))
+			}
+			if wait > 0 {
+				time.Sleep(wait)
+			}
+
+			backup, err := s.Create(context.Background())
+			if err != nil {
+				log.Printf("Ошибка резервного копирования: %v", err)
+				// Повторяем не раньше чем через час, чтобы не забить журнал
+				time.Sleep(min(s.cfg.Interval, time.Hour))
+				continue
+			}
+			log.Printf("Создана резервная копия %s", backup.Name())
+		}
+	}()
+}
+
+// copyDatabase копирует базу src в dst через online backup API SQLite.
+// Копия согласована: записи, сделанные в src во время копирования, либо попадут в нее целиком,
+// либо копирование начнется заново
+func copyDatabase(ctx context.Context, dst, src *sql.DB) error {
+	dstConn, err := dst.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer dstConn.Close()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return dstConn.Raw(func(dstDriver any) error {
+		return srcConn.Raw(func(srcDriver any) error {
+			dstSQLite, ok := dstDriver.(*sqlite3.SQLiteConn)
+			if !ok {
+				return errors.New("резервное копирование поддерживается только для SQLite")
+			}
+			srcSQLite, ok := srcDriver.(*sqlite3.SQLiteConn)
+			if !ok {
+				return errors.New("резервное копирование поддерживается только для SQLite")
+			}
+
+			backup, err := dstSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+			for {
+				done, err := backup.Step(backupPagesPerStep)
+				if err != nil {
+					backup.Finish()
+					return err
+				}
+				if done {
+					return backup.Finish()
+				}
+				// Между шагами даем поработать другим соединениям; занятая база тоже ждет здесь
+				select {
+				case <-ctx.Done():
+					backup.Finish()
+					return ctx.Err()
+				case <-time.After(10 * time.Millisecond):
+				}
+			}
+		})
+	})
+}
+
+// runBackupTask выполняет task в отдельной горутине, пока окно показывает индикатор:
+// копирование большой базы и перестроение индекса занимают заметное время.
+// done вызывается в потоке UI с результатом task
+func runBackupTask(w fyne.Window, title string, task func() error, done func(error)) {
+	progress := dialog.NewCustomWithoutButtons(title, widget.NewProgressBarInfinite(), w)
+	progress.Show()
+	go func() {
+		err := task()
+		fyne.Do(func() {
+			progress.Hide()
+			done(err)
+		})
+	}()
+}
+
+// showBackupsDialog показывает снимки базы с кнопками восстановления и создания нового снимка
+func showBackupsDialog(backups *BackupService, w fyne.Window) {
+	list := container.NewVBox()
+
+	var refresh func()
+	refresh = func() {
+		list.Objects = nil
+		items, err := backups.List()
+		if err != nil {
+			list.Add(widget.NewLabel("Ошибка чтения каталога резервных копий: " + err.Error()))
+		} else if len(items) == 0 {
+			list.Add(widget.NewLabel("Резервных копий пока нет"))
+		}
+		for _, backup := range items {
+			restoreBtn := widget.NewButtonWithIcon("Восстановить", theme.ContentUndoIcon(), func() {
+				dialog.ShowConfirm("Подтверждение",
+					fmt.Sprintf("Заменить базу снимком от %s?\nТекущая база будет сохранена в новый снимок.",
+						backup.Time.Format("02.01.2006 15:04:05")),
+					func(ok bool) {
+						if !ok {
+							return
+						}
+						var safety Backup
+						runBackupTask(w, "Восстановление базы", func() (err error) {
+							safety, err = backups.Restore(context.Background(), backup.Path)
+							return err
+						}, func(err error) {
+							refresh()
+							if err != nil {
+								dialog.ShowError(err, w)
+								return
+							}
+							dialog.ShowInformation("Успех",
+								fmt.Sprintf("База восстановлена, индекс перестроен.\nПрежняя база сохранена в %s", safety.Name()), w)
+						})
+					}, w)
+			})
+			restoreBtn.Importance = widget.WarningImportance
+
+			info := fmt.Sprintf("%s · %s · %.1f МБ", backup.Time.Format("02.01.2006 15:04:05"), backup.Name(), float64(backup.Size)/(1<<20))
+			list.Add(container.NewHBox(widget.NewLabel(info), layout.NewSpacer(), restoreBtn))
+		}
+		list.Refresh()
+	}
+	refresh()
+
+	createBtn := widget.NewButtonWithIcon("Создать копию сейчас", theme.DocumentSaveIcon(), func() {
+		var backup Backup
+		runBackupTask(w, "Резервное копирование", func() (err error) {
+			backup, err = backups.Create(context.Background())
+			return err
+		}, func(err error) {
+			refresh()
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			dialog.ShowInformation("Успех", "Создана резервная копия "+backup.Name(), w)
+		})
+	})
+	createBtn.Importance = widget.HighImportance
+
+	scroll := container.NewScroll(list)
+	scroll.SetMinSize(fyne.NewSize(700, 400))
+	content := container.NewBorder(container.NewHBox(layout.NewSpacer(), createBtn), nil, nil, nil, scroll)
+	dialog.ShowCustom("Резервные копии", "Закрыть", content, w)
+}
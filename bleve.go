@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/v2/analysis/char/regexp"
+	"github.com/blevesearch/bleve/v2/analysis/lang/ru"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// indexSchemaVersion увеличивается при любом изменении маппинга:
+// индекс со старой версией удаляется и строится заново из базы
+const indexSchemaVersion = "2"
+
+// schemaVersionKey — ключ внутреннего хранилища индекса с версией схемы
+var schemaVersionKey = []byte("schema_version")
+
+const (
+	russianAnalyzer = "ru_faq"
+	yoCharFilter    = "yo_to_ye"
+)
+
+// buildIndexMapping описывает индекс FAQ: русская морфология для вопроса и ответа,
+// категория и теги — ключевые поля для фильтра и подсчета по фасетам,
+// идентификатор записи не индексируется
+func buildIndexMapping() (mapping.IndexMapping, error) {
+	im := bleve.NewIndexMapping()
+
+	err := im.AddCustomCharFilter(yoCharFilter, map[string]any{
+		"type":    regexp.Name,
+		"regexp":  "[ёЁ]",
+		"replace": "е",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = im.AddCustomAnalyzer(russianAnalyzer, map[string]any{
+		"type":          custom.Name,
+		"char_filters":  []string{yoCharFilter},
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{lowercase.Name, ru.StopName, ru.SnowballStemmerName},
+	})
+	if err != nil {
+		return nil, err
+	}
+	im.DefaultAnalyzer = russianAnalyzer
+
+	textField := func() *mapping.FieldMapping {
+		field := bleve.NewTextFieldMapping()
+		field.Analyzer = russianAnalyzer
+		field.Store = true
+		field.IncludeTermVectors = true
+		return field
+	}
+
+	keywordField := func() *mapping.FieldMapping {
+		field := bleve.NewKeywordFieldMapping()
+		field.IncludeInAll = false
+		field.IncludeTermVectors = false
+		return field
+	}
+
+	faqMapping := bleve.NewDocumentStaticMapping()
+	faqMapping.AddFieldMappingsAt("Question", textField())
+	faqMapping.AddFieldMappingsAt("Answer", textField())
+	faqMapping.AddFieldMappingsAt("Category", keywordField())
+	faqMapping.AddFieldMappingsAt("Tags", keywordField())
+	im.DefaultMapping = faqMapping
+
+	return im, nil
+}
+
+// buildQuery ищет слова вопроса в полях Question и Answer с разным весом
+func buildQuery(question string, cfg SearchConfig) query.Query {
+	questionQuery := bleve.NewMatchQuery(question)
+	questionQuery.SetField("Question")
+	questionQuery.SetBoost(cfg.QuestionBoost)
+
+	answerQuery := bleve.NewMatchQuery(question)
+	answerQuery.SetField("Answer")
+	answerQuery.SetBoost(cfg.AnswerBoost)
+
+	return bleve.NewDisjunctionQuery(questionQuery, answerQuery)
+}
+
+// filterQuery ограничивает запрос категорией вместе с подкатегориями.
+// Условие на категорию не влияет на оценку, чтобы пороги уверенности работали как без фильтра
+func filterQuery(q query.Query, filter SearchFilter) query.Query {
+	if filter.Category == "" {
+		return q
+	}
+	categoryQuery := bleve.NewTermQuery(filter.Category)
+	categoryQuery.SetField("Category")
+	categoryQuery.SetBoost(0)
+	return bleve.NewConjunctionQuery(q, categoryQuery)
+}
+
+// createBleveIndex открывает индекс Bleve или создает пустой, если его еще нет
+// или он построен по другой версии маппинга. Документы добавляет проверка FAQService.CheckIndex
+func createBleveIndex(path string) (bleve.Index, error) {
+	index, err := bleve.Open(path)
+	switch {
+	case err == nil:
+		version, err := index.GetInternal(schemaVersionKey)
+		if err != nil {
+			index.Close()
+			return nil, err
+		}
+		if string(version) == indexSchemaVersion {
+			return index, nil
+		}
+		log.Printf("Схема индекса изменилась (%q -> %q), индекс будет построен заново", version, indexSchemaVersion)
+		index.Close()
+		if err := os.RemoveAll(path); err != nil {
+			return nil, fmt.Errorf("не удалось удалить устаревший индекс: %w", err)
+		}
+	case !errors.Is(err, bleve.ErrorIndexPathDoesNotExist):
+		return nil, err
+	}
+
+	indexMapping, err := buildIndexMapping()
+	if err != nil {
+		return nil, err
+	}
+	index, err = bleve.New(path, indexMapping)
+	if err != nil {
+		return nil, err
+	}
+	if err := index.SetInternal(schemaVersionKey, []byte(indexSchemaVersion)); err != nil {
+		index.Close()
+		return nil, err
+	}
+	return index, nil
+}
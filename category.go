@@ -0,0 +1,142 @@
+package main
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+)
+
+// categorySeparator разделяет уровни в пути категории: "Сеть/VPN"
+const categorySeparator = "/"
+
+// categoryNode — узел дерева категорий
+type categoryNode struct {
+	parentID sql.NullInt64
+	name     string
+}
+
+// normalizeCategory приводит путь категории к виду "Сеть/VPN": убирает пробелы и пустые уровни
+func normalizeCategory(path string) string {
+	var parts []string
+	for _, part := range strings.Split(path, categorySeparator) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, categorySeparator)
+}
+
+// categoryAncestors возвращает путь категории и пути всех ее родителей,
+// начиная с корня: "Сеть/VPN" -> ["Сеть", "Сеть/VPN"].
+// Так запись находится фильтром по любому уровню и учитывается в его количестве
+func categoryAncestors(path string) []string {
+	if path == "" {
+		return nil
+	}
+	parts := strings.Split(path, categorySeparator)
+	ancestors := make([]string, len(parts))
+	for i := range parts {
+		ancestors[i] = strings.Join(parts[:i+1], categorySeparator)
+	}
+	return ancestors
+}
+
+// inCategory сообщает, относится ли путь к категории filter или к одной из ее подкатегорий
+func inCategory(path, filter string) bool {
+	return filter == "" || path == filter || strings.HasPrefix(path, filter+categorySeparator)
+}
+
+// parseTags разбирает теги, введенные через запятую: без повторов, в нижнем регистре, по алфавиту
+func parseTags(text string) []string {
+	return normalizeTags(strings.Split(text, ","))
+}
+
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	var out []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// loadCategories загружает дерево категорий
+func loadCategories(db *sql.DB) (map[int]categoryNode, error) {
+	rows, err := db.Query("SELECT id, parent_id, name FROM categories")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	nodes := make(map[int]categoryNode)
+	for rows.Next() {
+		var id int
+		var node categoryNode
+		if err := rows.Scan(&id, &node.parentID, &node.name); err != nil {
+			return nil, err
+		}
+		nodes[id] = node
+	}
+	return nodes, rows.Err()
+}
+
+// categoryPath собирает полный путь категории по ее идентификатору
+func categoryPath(nodes map[int]categoryNode, id sql.NullInt64) string {
+	var parts []string
+	// Ограничение глубины защищает от цикла в испорченной базе
+	for depth := 0; id.Valid && depth < len(nodes); depth++ {
+		node, ok := nodes[int(id.Int64)]
+		if !ok {
+			break
+		}
+		parts = append([]string{node.name}, parts...)
+		id = node.parentID
+	}
+	return strings.Join(parts, categorySeparator)
+}
+
+// resolveCategory возвращает идентификатор категории по пути, создавая недостающие уровни.
+// Для пустого пути возвращает NULL
+func resolveCategory(tx *sql.Tx, path string) (sql.NullInt64, error) {
+	var id sql.NullInt64
+	if path == "" {
+		return id, nil
+	}
+	for _, name := range strings.Split(path, categorySeparator) {
+		var next int64
+		err := tx.QueryRow("SELECT id FROM categories WHERE parent_id IS ? AND name = ?", id, name).Scan(&next)
+		if err == sql.ErrNoRows {
+			res, err := tx.Exec("INSERT INTO categories (parent_id, name) VALUES (?, ?)", id, name)
+			if err != nil {
+				return id, err
+			}
+			next, err = res.LastInsertId()
+			if err != nil {
+				return id, err
+			}
+		} else if err != nil {
+			return id, err
+		}
+		id = sql.NullInt64{Int64: next, Valid: true}
+	}
+	return id, nil
+}
+
+// saveTags заменяет теги записи
+func saveTags(tx *sql.Tx, faqID int, tags []string) error {
+	if _, err := tx.Exec("DELETE FROM faq_tags WHERE faq_id = ?", faqID); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := tx.Exec("INSERT INTO faq_tags (faq_id, tag) VALUES (?, ?)", faqID, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,606 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+// runCommand выполняет подкоманду из командной строки вместо запуска окна
+func runCommand(cfg Config, args []string, out io.Writer) error {
+	switch args[0] {
+	case "gui":
+		return runGUI(cfg)
+	case "ask":
+		return runAskCommand(cfg, args[1:], out)
+	case "reindex":
+		return runReindexCommand(cfg, args[1:], out)
+	case "migrate":
+		return runMigrateCommand(cfg, args[1:], out)
+	case "import":
+		return runImportCommand(cfg, args[1:], out)
+	case "export":
+		return runExportCommand(cfg, args[1:], out)
+	case "site":
+		return runSiteCommand(cfg, args[1:], out)
+	case "backup":
+		return runBackupCommand(cfg, args[1:], out)
+	default:
+		return fmt.Errorf("неизвестная команда %q; доступные команды: gui, ask, reindex, migrate, import, export, site, backup", args[0])
+	}
+}
+
+// AskResult — ответ команды ask в JSON
+type AskResult struct {
+	Question  string      `json:"question"`
+	Source    string      `json:"source"` // exact, faq, llm или пусто, если ответа нет
+	Answer    string      `json:"answer"` // для faq пустой: ответ выбирают среди hits
+	FAQID     int         `json:"faq_id,omitempty"`
+	Model     string      `json:"model,omitempty"`
+	LatencyMS int64       `json:"latency_ms"`
+	HistoryID int         `json:"history_id,omitempty"`
+	Hits      []AskHit    `json:"hits"`
+	Sources   []AskSource `json:"sources"`
+}
+
+// AskHit — запись FAQ, найденная по вопросу
+type AskHit struct {
+	FAQID      int      `json:"faq_id"`
+	Question   string   `json:"question"`
+	Answer     string   `json:"answer"`
+	Category   string   `json:"category"`
+	Tags       []string `json:"tags"`
+	Score      float64  `json:"score"`
+	BleveScore float64  `json:"bleve_score,omitempty"`
+	Similarity float64  `json:"similarity,omitempty"`
+	Confident  bool     `json:"confident"`
+}
+
+// AskSource — запись FAQ, переданная модели как контекст
+type AskSource struct {
+	FAQID    int    `json:"faq_id"`
+	Question string `json:"question"`
+}
+
+// runAskCommand — support ask [флаги] вопрос
+//
+// Ищет ответ так же, как кнопка "Найти": точное совпадение, похожие записи и, если их нет, модель.
+// С -ai модель спрашивается и при найденных записях, как кнопкой "Ничего не подходит".
+// По умолчанию выводит JSON; "-" вместо вопроса — читать вопрос из stdin
+func runAskCommand(cfg Config, args []string, out io.Writer) error {
+	fset := flag.NewFlagSet("ask", flag.ContinueOnError)
+	fset.Usage = func() {
+		fmt.Fprintln(fset.Output(), "использование: support [флаги] ask [флаги вопроса] вопрос|-")
+		fset.PrintDefaults()
+	}
+	format := fset.String("format", FormatJSON, "формат вывода: json или text")
+	category := fset.String("category", "", "искать только в категории и ее подкатегориях")
+	ai := fset.Bool("ai", false, "спросить модель, даже если в базе нашлись похожие записи")
+	noModel := fset.Bool("no-model", false, "не обращаться к модели, даже если в базе ничего не нашлось")
+	noHistory := fset.Bool("no-history", false, "не сохранять вопрос в историю")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if *format != FormatJSON && *format != "text" {
+		return fmt.Errorf("неизвестный формат %q; доступны: json, text", *format)
+	}
+	question := strings.Join(fset.Args(), " ")
+	if question == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		question = string(data)
+	}
+	question = strings.TrimSpace(question)
+	if question == "" {
+		fset.Usage()
+		return errors.New("укажите вопрос")
+	}
+
+	started := time.Now()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	services, err := openServices(cfg)
+	if err != nil {
+		return err
+	}
+	defer services.Close()
+	services.syncVectors(ctx)
+	assistant := services.Assistant
+
+	lookup, err := assistant.Lookup(ctx, question, SearchFilter{Category: *category})
+	if err != nil {
+		return err
+	}
+
+	var entry HistoryEntry
+	switch {
+	case lookup.Exact != nil:
+		entry = HistoryEntry{
+			Question:   question,
+			Answer:     lookup.Exact.Answer,
+			Provenance: Provenance{Source: HistorySourceExact, FAQID: lookup.Exact.ID, Latency: time.Since(started)},
+		}
+	case len(lookup.Hits) > 0 && !*ai:
+		// Какую из записей взять, решает вызывающий, поэтому ответ в историю не пишется
+		entry = HistoryEntry{
+			Question:   question,
+			Provenance: Provenance{Source: HistorySourceFAQ, Latency: time.Since(started)},
+		}
+	case !*noModel:
+		prompt, err := assistant.Prompt(question, lookup.Hits)
+		if err != nil {
+			return err
+		}
+		// В текстовом виде ответ модели выводится по мере поступления
+		var onToken func(string)
+		if *format == "text" {
+			onToken = func(token string) {
+				fmt.Fprint(out, token)
+			}
+		}
+		answer, provenance, err := assistant.Generate(ctx, prompt, started, onToken)
+		if err != nil {
+			return err
+		}
+		entry = HistoryEntry{Question: question, Answer: answer, Provenance: provenance, Sources: prompt.Sources()}
+	}
+
+	result := AskResult{
+		Question:  question,
+		Source:    entry.Source,
+		Answer:    entry.Answer,
+		FAQID:     entry.FAQID,
+		Model:     entry.Model,
+		LatencyMS: entry.Latency.Milliseconds(),
+		Hits:      []AskHit{},
+		Sources:   []AskSource{},
+	}
+	for _, hit := range lookup.Hits[:min(len(lookup.Hits), cfg.Search.MaxResults)] {
+		tags := hit.Entry.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		result.Hits = append(result.Hits, AskHit{
+			FAQID:      hit.Entry.ID,
+			Question:   hit.Entry.Question,
+			Answer:     hit.Entry.Answer,
+			Category:   hit.Entry.Category,
+			Tags:       tags,
+			Score:      hit.Score,
+			BleveScore: hit.BleveScore,
+			Similarity: hit.Similarity,
+			Confident:  hit.Confident,
+		})
+	}
+	for _, source := range entry.Sources {
+		result.Sources = append(result.Sources, AskSource{FAQID: source.FAQID, Question: source.Question})
+	}
+	if entry.Source != "" && !*noHistory {
+		if result.HistoryID, err = saveToHistory(services.DB, entry); err != nil {
+			return err
+		}
+	}
+
+	if *format == FormatJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	return writeAskText(out, result, entry.Provenance)
+}
+
+// writeAskText выводит ответ команды ask для чтения человеком.
+// Ответ модели к этому моменту уже выведен по частям
+func writeAskText(out io.Writer, result AskResult, provenance Provenance) error {
+	switch result.Source {
+	case "":
+		fmt.Fprintln(out, "В базе ничего не найдено")
+		return nil
+	case HistorySourceLLM:
+		fmt.Fprintln(out)
+	case HistorySourceFAQ:
+		for i, hit := range result.Hits {
+			if i > 0 {
+				fmt.Fprintln(out)
+			}
+			fmt.Fprintf(out, "#%d %s (%.3f)\n%s\n", hit.FAQID, hit.Question, hit.Score, strings.TrimSpace(hit.Answer))
+		}
+	default:
+		fmt.Fprintln(out, strings.TrimSpace(result.Answer))
+	}
+	fmt.Fprintf(out, "\n%s\n", provenance.describe())
+	if len(result.Sources) > 0 {
+		fmt.Fprintln(out, "Источники:")
+		for _, source := range result.Sources {
+			fmt.Fprintf(out, "  #%d %s\n", source.FAQID, source.Question)
+		}
+	}
+	return nil
+}
+
+// runReindexCommand — support reindex [-embeddings=false]
+//
+// Строит индекс Bleve заново из базы и пересчитывает эмбеддинги новых и измененных записей
+func runReindexCommand(cfg Config, args []string, out io.Writer) error {
+	fset := flag.NewFlagSet("reindex", flag.ContinueOnError)
+	fset.Usage = func() {
+		fmt.Fprintln(fset.Output(), "использование: support [флаги] reindex [-embeddings=false]")
+		fset.PrintDefaults()
+	}
+	embeddings := fset.Bool("embeddings", true, "пересчитать эмбеддинги для векторного поиска (кроме режима bleve)")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := openDatabase(cfg.Paths.DB)
+	if err != nil {
+		return err
+	}
+	n, err := rebuildIndex(cfg, db)
+	db.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Индекс построен заново, проиндексировано записей: %d\n", n)
+
+	if !*embeddings || cfg.Search.Mode == SearchModeBleve {
+		return nil
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	services, err := openServices(cfg)
+	if err != nil {
+		return err
+	}
+	defer services.Close()
+	updated, err := services.Vectors.Sync(ctx, services.FAQ.Entries())
+	if err != nil {
+		return fmt.Errorf("ошибка расчета эмбеддингов: %w", err)
+	}
+	fmt.Fprintf(out, "Эмбеддинги обновлены, пересчитано записей: %d\n", updated)
+	return nil
+}
+
+// runMigrateCommand — support migrate [status|dry-run|up]
+//
+//	status  — список миграций и отметка о применении (по умолчанию)
+//	dry-run — SQL миграций, которые будут применены, без изменения базы
+//	up      — применить недостающие миграции
+func runMigrateCommand(cfg Config, args []string, out io.Writer) error {
+	fset := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fset.Usage = func() {
+		fmt.Fprintln(fset.Output(), "использование: support [флаги] migrate [status|dry-run|up]")
+	}
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	action := "status"
+	if fset.NArg() > 0 {
+		action = fset.Arg(0)
+	}
+
+	// openDatabase сам применяет миграции, поэтому здесь база открывается напрямую
+	db, err := sql.Open("sqlite3", cfg.Paths.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch action {
+	case "status":
+		status, err := migrationStatus(db)
+		if err != nil {
+			return err
+		}
+		for _, s := range status {
+			state := "не применена"
+			if s.Applied() {
+				state = "применена " + s.AppliedAt
+			}
+			fmt.Fprintf(out, "%04d_%s\t%s\n", s.Version, s.Name, state)
+		}
+	case "dry-run":
+		pending, err := pendingMigrations(db)
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			fmt.Fprintln(out, "Схема актуальна")
+		}
+		for _, m := range pending {
+			fmt.Fprintf(out, "-- %04d_%s\n%s\n", m.Version, m.Name, strings.TrimSpace(m.SQL))
+		}
+	case "up":
+		applied, err := runMigrations(db)
+		for _, m := range applied {
+			fmt.Fprintf(out, "Применена миграция %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(out, "Схема актуальна")
+		}
+	default:
+		fset.Usage()
+		return fmt.Errorf("неизвестное действие %q", action)
+	}
+	return nil
+}
+
+// runImportCommand — support import [флаги] файл.csv|файл.json
+//
+// Столбцы question/вопрос, answer/ответ, category/категория, tags/теги узнаются по названию,
+// другие указываются флагами. Сначала выводится предпросмотр, затем записи добавляются одной транзакцией
+func runImportCommand(cfg Config, args []string, out io.Writer) error {
+	fset := flag.NewFlagSet("import", flag.ContinueOnError)
+	fset.Usage = func() {
+		fmt.Fprintln(fset.Output(), "использование: support [флаги] import [флаги импорта] файл.csv|файл.json")
+		fset.PrintDefaults()
+	}
+	format := fset.String("format", "", "формат файла: csv или json; по умолчанию — по расширению")
+	question := fset.String("question", "", "столбец с вопросом")
+	answer := fset.String("answer", "", "столбец с ответом")
+	category := fset.String("category", "", "столбец с категорией")
+	tags := fset.String("tags", "", "столбец с тегами через запятую")
+	update := fset.Bool("update", false, "обновлять записи, вопрос которых уже есть в базе")
+	dryRun := fset.Bool("dry-run", false, "только показать, что будет импортировано")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if fset.NArg() != 1 {
+		fset.Usage()
+		return errors.New("укажите один файл для импорта")
+	}
+	path := fset.Arg(0)
+
+	if *format == "" {
+		var err error
+		if *format, err = formatFromPath(path); err != nil {
+			return err
+		}
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	table, err := readImport(file, *format)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	mapping := guessMapping(table.Columns)
+	setIfNotEmpty(&mapping.Question, *question)
+	setIfNotEmpty(&mapping.Answer, *answer)
+	setIfNotEmpty(&mapping.Category, *category)
+	setIfNotEmpty(&mapping.Tags, *tags)
+	if mapping.Question == "" || mapping.Answer == "" {
+		return fmt.Errorf("не найдены столбцы вопроса и ответа среди %q; укажите их флагами -question и -answer", table.Columns)
+	}
+
+	services, err := openServices(cfg)
+	if err != nil {
+		return err
+	}
+	defer services.Close()
+	faq := services.FAQ
+
+	rows := prepareImport(table, mapping, faq)
+	for _, row := range rows {
+		if row.Status != ImportNew {
+			fmt.Fprintf(out, "строка %d: %s\t%s\n", row.Line, row.describe(), row.Entry.Question)
+		}
+	}
+	fmt.Fprintln(out, summarizeImport(rows))
+	if *dryRun {
+		return nil
+	}
+
+	result, err := faq.Import(rows, *update)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, result)
+	return nil
+}
+
+// runExportCommand — support export [-format json|csv|markdown] faq|favorites|history файл|каталог
+//
+// Формат по умолчанию определяется по расширению файла; "-" вместо файла — вывод в stdout.
+// В Markdown выгружается только FAQ, в каталог
+func runExportCommand(cfg Config, args []string, out io.Writer) error {
+	fset := flag.NewFlagSet("export", flag.ContinueOnError)
+	fset.Usage = func() {
+		fmt.Fprintln(fset.Output(), "использование: support [флаги] export [-format json|csv|markdown] faq|favorites|history файл|каталог|-")
+		fset.PrintDefaults()
+	}
+	format := fset.String("format", "", "формат: json, csv или markdown; по умолчанию — по расширению файла")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if fset.NArg() != 2 {
+		fset.Usage()
+		return errors.New("укажите, что выгрузить, и куда")
+	}
+	kind, path := fset.Arg(0), fset.Arg(1)
+
+	if *format == "" {
+		var err error
+		if path == "-" {
+			*format = FormatJSON
+		} else if *format, err = formatFromPath(path); err != nil {
+			return err
+		}
+	}
+
+	services, err := openServices(cfg)
+	if err != nil {
+		return err
+	}
+	defer services.Close()
+
+	if *format == FormatMarkdown {
+		if kind != ExportFAQ {
+			return errors.New("в Markdown выгружается только faq")
+		}
+		n, err := exportMarkdown(path, services.FAQ.Entries())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Записано записей: %d\n", n)
+		return nil
+	}
+
+	table, err := exportTable(kind, services.DB, services.FAQ, services.Favorites)
+	if err != nil {
+		return err
+	}
+	if path == "-" {
+		return writeExport(out, table, *format)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeExport(file, table, *format); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Выгружено записей: %d\n", len(table.Rows))
+	return nil
+}
+
+// runSiteCommand — support site [-title заголовок] каталог
+//
+// Собирает статический сайт базы знаний, который можно выложить на любой веб-сервер
+func runSiteCommand(cfg Config, args []string, out io.Writer) error {
+	fset := flag.NewFlagSet("site", flag.ContinueOnError)
+	fset.Usage = func() {
+		fmt.Fprintln(fset.Output(), "использование: support [флаги] site [-title заголовок] каталог")
+		fset.PrintDefaults()
+	}
+	title := fset.String("title", "База знаний технической поддержки НИТИ", "заголовок сайта")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if fset.NArg() != 1 {
+		fset.Usage()
+		return errors.New("укажите каталог для сайта")
+	}
+
+	services, err := openServices(cfg)
+	if err != nil {
+		return err
+	}
+	defer services.Close()
+	faq := services.FAQ
+
+	n, err := generateSite(fset.Arg(0), *title, faq.Entries())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Сайт собран в %s, страниц записей: %d\n", fset.Arg(0), n)
+	return nil
+}
+
+// runBackupCommand — support backup [list|create|restore файл]
+//
+//	list    — снимки базы, начиная с последнего (по умолчанию)
+//	create  — сделать снимок и удалить лишние старые
+//	restore — заменить базу снимком и построить индекс заново
+func runBackupCommand(cfg Config, args []string, out io.Writer) error {
+	fset := flag.NewFlagSet("backup", flag.ContinueOnError)
+	fset.Usage = func() {
+		fmt.Fprintln(fset.Output(), "использование: support [флаги] backup [list|create|restore файл]")
+	}
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	action := "list"
+	if fset.NArg() > 0 {
+		action = fset.Arg(0)
+	}
+
+	db, err := openDatabase(cfg.Paths.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	backups := NewBackupService(db, cfg.Paths.DB, cfg.Backup)
+
+	switch action {
+	case "list":
+		items, err := backups.List()
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			fmt.Fprintln(out, "Резервных копий нет")
+		}
+		for _, b := range items {
+			fmt.Fprintf(out, "%s\t%s\t%d\n", b.Time.Format(time.DateTime), b.Path, b.Size)
+		}
+	case "create":
+		backup, err := backups.Create(context.Background())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Создана резервная копия %s\n", backup.Path)
+	case "restore":
+		if fset.NArg() != 2 {
+			fset.Usage()
+			return errors.New("укажите файл снимка")
+		}
+		safety, err := backups.Restore(context.Background(), fset.Arg(1))
+		if safety.Path != "" {
+			fmt.Fprintf(out, "Прежняя база сохранена в %s\n", safety.Path)
+		}
+		if err != nil {
+			return err
+		}
+
+		n, err := rebuildIndex(cfg, db)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "База восстановлена из %s, проиндексировано записей: %d\n", fset.Arg(1), n)
+	default:
+		fset.Usage()
+		return fmt.Errorf("неизвестное действие %q", action)
+	}
+	return nil
+}
+
+// rebuildIndex строит индекс Bleve заново из базы db. Старый индекс удаляется не открывая:
+// он может быть поврежден или относиться к другой базе. Возвращает число проиндексированных записей
+func rebuildIndex(cfg Config, db *sql.DB) (int, error) {
+	if err := os.RemoveAll(cfg.Paths.Index); err != nil {
+		return 0, err
+	}
+	index, err := createBleveIndex(cfg.Paths.Index)
+	if err != nil {
+		return 0, err
+	}
+	defer index.Close()
+	faq, err := NewFAQService(db, index, cfg.Operator.Name)
+	if err != nil {
+		return 0, err
+	}
+	return faq.Rebuild()
+}
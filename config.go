@@ -0,0 +1,274 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// defaultConfigPath — файл настроек по умолчанию, лежит рядом с faq.db
+const defaultConfigPath = "support.toml"
+
+// Config содержит все настройки приложения
+type Config struct {
+	Ollama   OllamaConfig   `toml:"ollama"`
+	RAG      RAGConfig      `toml:"rag"`
+	Search   SearchConfig   `toml:"search"`
+	Paths    PathsConfig    `toml:"paths"`
+	Operator OperatorConfig `toml:"operator"`
+	Trash    TrashConfig    `toml:"trash"`
+	Backup   BackupConfig   `toml:"backup"`
+}
+
+// OllamaConfig описывает подключение к Ollama и параметры генерации
+type OllamaConfig struct {
+	URL           string         `toml:"url"`
+	Model         string         `toml:"model"`
+	EmbedModel    string         `toml:"embed_model"`
+	Options       map[string]any `toml:"options"`
+	Timeout       time.Duration  `toml:"timeout"`
+	StatusTimeout time.Duration  `toml:"status_timeout"`
+}
+
+// RAGConfig задает, сколько записей FAQ и в каком виде попадает в запрос к модели
+type RAGConfig struct {
+	TopK             int    `toml:"top_k"`
+	MaxContextTokens int    `toml:"max_context_tokens"`
+	PromptTemplate   string `toml:"prompt_template"`
+}
+
+// Режимы поиска похожих вопросов
+const (
+	SearchModeBleve  = "bleve"
+	SearchModeVector = "vector"
+	SearchModeHybrid = "hybrid"
+)
+
+// Способы объединения результатов в гибридном режиме
+const (
+	FusionRRF      = "rrf"
+	FusionWeighted = "weighted"
+)
+
+// SearchConfig задает режим поиска, способ объединения результатов и пороги уверенности
+type SearchConfig struct {
+	Mode          string  `toml:"mode"`
+	MaxResults    int     `toml:"max_results"`
+	QuestionBoost float64 `toml:"question_boost"`
+	AnswerBoost   float64 `toml:"answer_boost"`
+	Fusion        string  `toml:"fusion"`
+	RRFK          float64 `toml:"rrf_k"`
+	BleveWeight   float64 `toml:"bleve_weight"`
+	VectorWeight  float64 `toml:"vector_weight"`
+	// FeedbackWeight — насколько отрицательные оценки операторов понижают запись; 0 — не учитывать
+	FeedbackWeight float64          `toml:"feedback_weight"`
+	Confidence     ConfidencePolicy `toml:"confidence"`
+}
+
+// PathsConfig содержит пути к файлам базы, индекса и изображений
+type PathsConfig struct {
+	DB    string `toml:"db"`
+	Index string `toml:"index"`
+	Icon  string `toml:"icon"`
+	Logo  string `toml:"logo"`
+}
+
+// OperatorConfig описывает оператора, от имени которого вносятся изменения в базу
+type OperatorConfig struct {
+	// Name записывается в историю правок; по умолчанию — имя пользователя ОС
+	Name string `toml:"name"`
+}
+
+// TrashConfig задает, сколько хранятся удаленные записи
+type TrashConfig struct {
+	// RetentionDays — через сколько дней записи из корзины удаляются окончательно; 0 — не удалять
+	RetentionDays int `toml:"retention_days"`
+}
+
+// BackupConfig задает, куда, как часто делаются снимки базы и сколько их хранится
+type BackupConfig struct {
+	Dir string `toml:"dir"`
+	// Interval — период снимков по расписанию; 0 — только по запросу
+	Interval time.Duration `toml:"interval"`
+	// Keep — сколько последних снимков хранить; 0 — все
+	Keep int `toml:"keep"`
+}
+
+// defaultConfig возвращает настройки, с которыми приложение работало до появления файла конфигурации
+func defaultConfig() Config {
+	return Config{
+		Ollama: OllamaConfig{
+			URL:        "http://172.16.10.228:11434",
+			Model:      "mistral",
+			EmbedModel: "nomic-embed-text",
+			Options: map[string]any{
+				"temperature": 0.7,
+				"top_p":       0.9,
+				"num_predict": 2048,
+			},
+			Timeout:       5 * time.Minute,
+			StatusTimeout: 5 * time.Second,
+		},
+		RAG: RAGConfig{
+			TopK:             3,
+			MaxContextTokens: 2048,
+			PromptTemplate:   defaultPromptTemplate,
+		},
+		Search: SearchConfig{
+			Mode:           SearchModeBleve,
+			MaxResults:     5,
+			QuestionBoost:  2,
+			AnswerBoost:    1,
+			Fusion:         FusionRRF,
+			RRFK:           60,
+			BleveWeight:    0.5,
+			VectorWeight:   0.5,
+			FeedbackWeight: 0.5,
+			Confidence: ConfidencePolicy{
+				MinBleveScore: 0.3,
+				MinSimilarity: 0.75,
+			},
+		},
+		Paths: PathsConfig{
+			DB:    "faq.db",
+			Index: "faq.bleve",
+			Icon:  "logo.png",
+			Logo:  "niti_logo_140x300.jpg",
+		},
+		Operator: OperatorConfig{
+			Name: systemUserName(),
+		},
+		Trash: TrashConfig{
+			RetentionDays: 30,
+		},
+		Backup: BackupConfig{
+			Dir:      "backups",
+			Interval: 24 * time.Hour,
+			Keep:     7,
+		},
+	}
+}
+
+// systemUserName возвращает имя пользователя ОС или пустую строку, если его не удалось узнать
+func systemUserName() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	return u.Username
+}
+
+// loadConfig собирает настройки в порядке приоритета:
+// значения по умолчанию, файл конфигурации, переменные окружения, флаги командной строки.
+// Возвращает также аргументы после флагов — подкоманду и ее параметры
+func loadConfig(args []string) (Config, []string, error) {
+	cfg := defaultConfig()
+
+	fset := flag.NewFlagSet("support", flag.ContinueOnError)
+	configPath := fset.String("config", envOr("SUPPORT_CONFIG", defaultConfigPath), "путь к файлу настроек (TOML)")
+	ollamaURL := fset.String("ollama-url", "", "адрес Ollama, например http://localhost:11434")
+	model := fset.String("model", "", "модель Ollama для генерации ответов")
+	dbPath := fset.String("db", "", "путь к базе SQLite")
+	indexPath := fset.String("index", "", "путь к индексу Bleve")
+	if err := fset.Parse(args); err != nil {
+		return cfg, nil, err
+	}
+
+	if err := cfg.loadFile(*configPath); err != nil {
+		return cfg, nil, err
+	}
+
+	cfg.applyEnv()
+
+	setIfNotEmpty(&cfg.Ollama.URL, *ollamaURL)
+	setIfNotEmpty(&cfg.Ollama.Model, *model)
+	setIfNotEmpty(&cfg.Paths.DB, *dbPath)
+	setIfNotEmpty(&cfg.Paths.Index, *indexPath)
+
+	// Пустое имя в файле настроек считается незаданным, иначе правки сохранятся без автора
+	if strings.TrimSpace(cfg.Operator.Name) == "" {
+		cfg.Operator.Name = systemUserName()
+	}
+
+	return cfg, fset.Args(), cfg.validate()
+}
+
+// loadFile накладывает значения из TOML-файла; отсутствие файла не считается ошибкой
+func (c *Config) loadFile(path string) error {
+	_, err := toml.DecodeFile(path, c)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка чтения настроек %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv накладывает переменные окружения SUPPORT_*
+func (c *Config) applyEnv() {
+	setIfNotEmpty(&c.Ollama.URL, os.Getenv("SUPPORT_OLLAMA_URL"))
+	setIfNotEmpty(&c.Ollama.Model, os.Getenv("SUPPORT_OLLAMA_MODEL"))
+	setIfNotEmpty(&c.Paths.DB, os.Getenv("SUPPORT_DB"))
+	setIfNotEmpty(&c.Paths.Index, os.Getenv("SUPPORT_INDEX"))
+	setIfNotEmpty(&c.Operator.Name, os.Getenv("SUPPORT_OPERATOR"))
+}
+
+func (c *Config) validate() error {
+	if c.Ollama.URL == "" {
+		return errors.New("не задан адрес Ollama (ollama.url)")
+	}
+	if c.Ollama.Model == "" {
+		return errors.New("не задана модель Ollama (ollama.model)")
+	}
+	if c.Search.MaxResults < 1 {
+		return errors.New("search.max_results должен быть не меньше 1")
+	}
+	if c.RAG.TopK < 1 {
+		return errors.New("rag.top_k должен быть не меньше 1")
+	}
+	switch c.Search.Mode {
+	case SearchModeBleve, SearchModeVector, SearchModeHybrid:
+	default:
+		return fmt.Errorf("неизвестный режим поиска %q (search.mode)", c.Search.Mode)
+	}
+	if c.Search.Fusion != FusionRRF && c.Search.Fusion != FusionWeighted {
+		return fmt.Errorf("неизвестный способ объединения %q (search.fusion)", c.Search.Fusion)
+	}
+	if c.Search.FeedbackWeight < 0 {
+		return errors.New("search.feedback_weight не может быть отрицательным")
+	}
+	if c.Trash.RetentionDays < 0 {
+		return errors.New("trash.retention_days не может быть отрицательным")
+	}
+	if c.Backup.Dir == "" {
+		return errors.New("не задан каталог резервных копий (backup.dir)")
+	}
+	if c.Backup.Interval < 0 || c.Backup.Keep < 0 {
+		return errors.New("backup.interval и backup.keep не могут быть отрицательными")
+	}
+	if c.Paths.DB == "" || c.Paths.Index == "" {
+		return errors.New("не заданы пути к базе и индексу (paths.db, paths.index)")
+	}
+	return nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func setIfNotEmpty(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldText string
+		newText string
+		want    []DiffLine
+	}{
+		{
+			name: "оба текста пусты",
+		},
+		{
+			name:    "было пусто",
+			newText: "Шаг 1\nШаг 2",
+			want:    []DiffLine{{DiffInsert, "Шаг 1"}, {DiffInsert, "Шаг 2"}},
+		},
+		{
+			name:    "стало пусто",
+			oldText: "Шаг 1\nШаг 2",
+			want:    []DiffLine{{DiffDelete, "Шаг 1"}, {DiffDelete, "Шаг 2"}},
+		},
+		{
+			name:    "без изменений, разные переводы строк",
+			oldText: "Шаг 1\r\nШаг 2",
+			newText: "Шаг 1\nШаг 2",
+			want:    []DiffLine{{DiffEqual, "Шаг 1"}, {DiffEqual, "Шаг 2"}},
+		},
+		{
+			name:    "замена строки в середине",
+			oldText: "Шаг 1\nШаг 2\nШаг 3",
+			newText: "Шаг 1\nНовый шаг\nШаг 3",
+			want:    []DiffLine{{DiffEqual, "Шаг 1"}, {DiffDelete, "Шаг 2"}, {DiffInsert, "Новый шаг"}, {DiffEqual, "Шаг 3"}},
+		},
+		{
+			name:    "строки добавлены в начало и конец",
+			oldText: "Шаг 2",
+			newText: "Шаг 1\nШаг 2\nШаг 3",
+			want:    []DiffLine{{DiffInsert, "Шаг 1"}, {DiffEqual, "Шаг 2"}, {DiffInsert, "Шаг 3"}},
+		},
+		{
+			name:    "пустая строка считается строкой",
+			oldText: "Шаг 1\n",
+			newText: "Шаг 1",
+			want:    []DiffLine{{DiffEqual, "Шаг 1"}, {DiffDelete, ""}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffLines(tt.oldText, tt.newText); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffLines(%q, %q) = %v, ожидалось %v", tt.oldText, tt.newText, got, tt.want)
+			}
+		})
+	}
+}
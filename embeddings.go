@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// errNoVectors возвращается, пока эмбеддинги не посчитаны
+var errNoVectors = errors.New("векторный индекс еще не готов")
+
+// VectorStore хранит эмбеддинги записей FAQ в SQLite и держит их копию в памяти для поиска
+type VectorStore struct {
+	db     *sql.DB
+	ollama *OllamaClient
+	model  string
+
+	syncMu  sync.Mutex // Sync может быть запущен повторно, пока идет предыдущий
+	mu      sync.RWMutex
+	vectors map[int][]float32
+}
+
+// VectorHit — запись FAQ и ее косинусное сходство с вопросом
+type VectorHit struct {
+	FAQID      int
+	Similarity float64
+}
+
+// NewVectorStore создает хранилище эмбеддингов для указанной модели
+func NewVectorStore(db *sql.DB, ollama *OllamaClient, model string) *VectorStore {
+	return &VectorStore{
+		db:      db,
+		ollama:  ollama,
+		model:   model,
+		vectors: make(map[int][]float32),
+	}
+}
+
+// Sync пересчитывает эмбеддинги только для новых и измененных записей,
+// удаляет векторы удаленных записей и загружает актуальные векторы в память.
+// Возвращает число пересчитанных записей
+func (s *VectorStore) Sync(ctx context.Context, entries []FAQEntry) (int, error) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	stored, err := s.loadHashes()
+	if err != nil {
+		return 0, err
+	}
+
+	// Даже если Ollama недоступна на середине, уже посчитанные векторы остаются в поиске:
+	// до пересчета у измененной записи остается прежний вектор
+	vectors := make(map[int][]float32, len(entries))
+	for _, entry := range entries {
+		if old, ok := stored[entry.ID]; ok {
+			vectors[entry.ID] = old.vector
+		}
+	}
+	defer func() {
+		s.mu.Lock()
+		s.vectors = vectors
+		s.mu.Unlock()
+	}()
+
+	updated := 0
+	for _, entry := range entries {
+		hash := contentHash(entry)
+		if old, ok := stored[entry.ID]; ok && old.hash == hash {
+			continue
+		}
+
+		embedding, err := s.ollama.embed(ctx, embeddingText(entry))
+		if err != nil {
+			return updated, fmt.Errorf("эмбеддинг записи #%d: %w", entry.ID, err)
+		}
+		vector := toFloat32(embedding)
+		if err := s.save(entry.ID, hash, vector); err != nil {
+			return updated, err
+		}
+		vectors[entry.ID] = vector
+		updated++
+	}
+
+	live := make(map[int]bool, len(entries))
+	for _, entry := range entries {
+		live[entry.ID] = true
+	}
+	for id := range stored {
+		if !live[id] {
+			if _, err := s.db.Exec("DELETE FROM faq_embeddings WHERE faq_id = ? AND model = ?", id, s.model); err != nil {
+				return updated, err
+			}
+		}
+	}
+	return updated, nil
+}
+
+// Search возвращает k записей, наиболее близких к вопросу по косинусному сходству.
+// keep отбирает записи, среди которых идет поиск; nil — все записи
+func (s *VectorStore) Search(ctx context.Context, question string, k int, keep func(faqID int) bool) ([]VectorHit, error) {
+	s.mu.RLock()
+	empty := len(s.vectors) == 0
+	s.mu.RUnlock()
+	if empty {
+		return nil, errNoVectors
+	}
+
+	embedding, err := s.ollama.embed(ctx, question)
+	if err != nil {
+		return nil, err
+	}
+	query := toFloat32(embedding)
+
+	s.mu.RLock()
+	hits := make([]VectorHit, 0, len(s.vectors))
+	for id, vector := range s.vectors {
+		if keep != nil && !keep(id) {
+			continue
+		}
+		hits = append(hits, VectorHit{FAQID: id, Similarity: cosineSimilarity(query, vector)})
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		return hits[i].Similarity > hits[j].Similarity
+	})
+	if len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits, nil
+}
+
+type storedVector struct {
+	hash   string
+	vector []float32
+}
+
+func (s *VectorStore) loadHashes() (map[int]storedVector, error) {
+	rows, err := s.db.Query("SELECT faq_id, content_hash, vector FROM faq_embeddings WHERE model = ?", s.model)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stored := make(map[int]storedVector)
+	for rows.Next() {
+		var id int
+		var hash string
+		var blob []byte
+		if err := rows.Scan(&id, &hash, &blob); err != nil {
+			return nil, err
+		}
+		stored[id] = storedVector{hash: hash, vector: decodeVector(blob)}
+	}
+	return stored, rows.Err()
+}
+
+func (s *VectorStore) save(faqID int, hash string, vector []float32) error {
+	_, err := s.db.Exec(`
+		INSERT INTO faq_embeddings (faq_id, model, content_hash, vector, updated_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (faq_id, model) DO UPDATE SET
+			content_hash = excluded.content_hash,
+			vector = excluded.vector,
+			updated_at = excluded.updated_at
+	`, faqID, s.model, hash, encodeVector(vector))
+	return err
+}
+
+// embeddingText — текст записи, по которому считается эмбеддинг
+func embeddingText(entry FAQEntry) string {
+	return entry.Question + "\n" + entry.Answer
+}
+
+// contentHash позволяет понять, изменилась ли запись с момента расчета вектора
+func contentHash(entry FAQEntry) string {
+	sum := sha256.Sum256([]byte(embeddingText(entry)))
+	return hex.EncodeToString(sum[:])
+}
+
+func toFloat32(v []float64) []float32 {
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = float32(x)
+	}
+	return out
+}
+
+// encodeVector упаковывает вектор в BLOB (float32, little-endian)
+func encodeVector(v []float32) []byte {
+	buf := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(x))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) []float32 {
+	v := make([]float32, len(buf)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return v
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
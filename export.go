@@ -0,0 +1,438 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Что можно выгрузить
+const (
+	ExportFAQ       = "faq"
+	ExportFavorites = "favorites"
+	ExportHistory   = "history"
+)
+
+// FormatMarkdown — выгрузка FAQ в каталог Markdown-файлов по категориям
+const FormatMarkdown = "markdown"
+
+// exportKinds — выгружаемые данные и их названия для оператора
+var exportKinds = []struct {
+	value string
+	title string
+}{
+	{ExportFAQ, "FAQ"},
+	{ExportFavorites, "Избранное"},
+	{ExportHistory, "История"},
+}
+
+// ExportTable — выгружаемые данные: столбцы в порядке вывода и строки со значениями по столбцам
+type ExportTable struct {
+	Columns []string
+	Rows    []map[string]any
+}
+
+// faqExportTable готовит записи FAQ к выгрузке. Названия столбцов совпадают с теми,
+// которые узнает импорт, поэтому выгруженный файл можно загрузить обратно
+func faqExportTable(entries []FAQEntry) ExportTable {
+	table := ExportTable{Columns: []string{"id", "question", "answer", "category", "tags"}}
+	for _, entry := range entries {
+		tags := entry.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		table.Rows = append(table.Rows, map[string]any{
+			"id":       entry.ID,
+			"question": entry.Question,
+			"answer":   entry.Answer,
+			"category": entry.Category,
+			"tags":     tags,
+		})
+	}
+	return table
+}
+
+// favoritesExportTable готовит к выгрузке избранное со всех папок
+func favoritesExportTable(favorites *FavoritesStore) (ExportTable, error) {
+	list, err := favorites.List(-1)
+	if err != nil {
+		return ExportTable{}, err
+	}
+	folders, err := favorites.Folders()
+	if err != nil {
+		return ExportTable{}, err
+	}
+	folderNames := make(map[int]string, len(folders))
+	for _, folder := range folders {
+		folderNames[folder.ID] = folder.Name
+	}
+
+	table := ExportTable{Columns: []string{"id", "question", "answer", "folder", "note", "faq_id", "history_id", "created_at"}}
+	for _, f := range list {
+		table.Rows = append(table.Rows, map[string]any{
+			"id":         f.ID,
+			"question":   f.Question,
+			"answer":     f.Answer,
+			"folder":     folderNames[f.FolderID],
+			"note":       f.Note,
+			"faq_id":     f.Ref.FAQID,
+			"history_id": f.Ref.HistoryID,
+			"created_at": f.CreatedAt,
+		})
+	}
+	return table, nil
+}
+
+// historyExportTable готовит к выгрузке всю историю вне корзины
+func historyExportTable(db *sql.DB) (ExportTable, error) {
+	// LIMIT -1 в SQLite снимает ограничение
+	entries, _, err := searchHistory(db, HistoryQuery{Limit: -1})
+	if err != nil {
+		return ExportTable{}, err
+	}
+
+	table := ExportTable{Columns: []string{
+		"id", "date", "question", "answer", "source", "faq_id", "score",
+		"model", "options", "latency_ms", "sources", "promoted_faq_id",
+	}}
+	for _, entry := range entries {
+		sources := make([]string, 0, len(entry.Sources))
+		for _, source := range entry.Sources {
+			sources = append(sources, source.String())
+		}
+		table.Rows = append(table.Rows, map[string]any{
+			"id":              entry.ID,
+			"date":            entry.Date,
+			"question":        entry.Question,
+			"answer":          entry.Answer,
+			"source":          entry.Source,
+			"faq_id":          entry.FAQID,
+			"score":           entry.Score,
+			"model":           entry.Model,
+			"options":         entry.Options,
+			"latency_ms":      entry.Latency.Milliseconds(),
+			"sources":         sources,
+			"promoted_faq_id": entry.PromotedFAQID,
+		})
+	}
+	return table, nil
+}
+
+// exportTable собирает выгружаемые данные по их виду
+func exportTable(kind string, db *sql.DB, faq *FAQService, favorites *FavoritesStore) (ExportTable, error) {
+	switch kind {
+	case ExportFAQ:
+		return faqExportTable(faq.Entries()), nil
+	case ExportFavorites:
+		return favoritesExportTable(favorites)
+	case ExportHistory:
+		return historyExportTable(db)
+	default:
+		return ExportTable{}, fmt.Errorf("неизвестный вид данных %q; доступны: faq, favorites, history", kind)
+	}
+}
+
+// writeExport записывает данные в JSON (массив объектов) или CSV с заголовком.
+// В CSV списки записываются через запятую, как их читает импорт
+func writeExport(w io.Writer, table ExportTable, format string) error {
+	switch format {
+	case FormatJSON:
+		rows := table.Rows
+		if rows == nil {
+			rows = []map[string]any{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(rows)
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(table.Columns); err != nil {
+			return err
+		}
+		for _, row := range table.Rows {
+			record := make([]string, len(table.Columns))
+			for i, column := range table.Columns {
+				record[i] = csvValue(row[column])
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		return fmt.Errorf("неизвестный формат %q", format)
+	}
+}
+
+func csvValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ", ")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// exportMarkdown выгружает записи FAQ в каталог dir: по подкаталогу на каждый уровень категории,
+// по файлу на запись и index.md со списком всех записей. Файлы записей, оставшиеся от прошлой выгрузки
+// (запись удалена, переименована или перенесена), удаляются. Возвращает число записанных файлов записей
+func exportMarkdown(dir string, entries []FAQEntry) (int, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return 0, err
+	}
+
+	byCategory := make(map[string][]FAQEntry)
+	for _, entry := range entries {
+		byCategory[entry.Category] = append(byCategory[entry.Category], entry)
+	}
+	categories := make([]string, 0, len(byCategory))
+	for category := range byCategory {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	var index strings.Builder
+	index.WriteString("# База знаний\n")
+	written := 0
+	files := make(map[string]bool)
+	for _, category := range categories {
+		title := category
+		if title == "" {
+			title = "Без категории"
+		}
+		fmt.Fprintf(&index, "\n## %s\n\n", title)
+
+		var parts []string
+		if category != "" {
+			for _, level := range strings.Split(category, categorySeparator) {
+				parts = append(parts, fileName(level))
+			}
+		}
+		categoryDir := filepath.Join(append([]string{dir}, parts...)...)
+		if err := os.MkdirAll(categoryDir, 0o755); err != nil {
+			return written, err
+		}
+
+		for _, entry := range byCategory[category] {
+			name := fmt.Sprintf("%d-%s.md", entry.ID, fileName(entry.Question))
+			path := filepath.Join(categoryDir, name)
+			if err := os.WriteFile(path, []byte(entryMarkdown(entry)), 0o644); err != nil {
+				return written, err
+			}
+			files[path] = true
+			written++
+			link := strings.Join(append(parts, name), "/")
+			fmt.Fprintf(&index, "- [%s](%s)\n", markdownInline(entry.Question), link)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "index.md"), []byte(index.String()), 0o644); err != nil {
+		return written, err
+	}
+	return written, removeStaleMarkdown(dir, files)
+}
+
+// removeStaleMarkdown удаляет в dir и его подкаталогах файлы записей вида <id>-<вопрос>.md,
+// которых нет в files, и каталоги, опустевшие после этого. Остальные файлы не трогает
+func removeStaleMarkdown(dir string, files map[string]bool) error {
+	dir = filepath.Clean(dir)
+	var stale []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || files[path] || !isEntryMarkdown(d.Name()) {
+			return nil
+		}
+		stale = append(stale, path)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, path := range stale {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		// Каталог категории, в которой не осталось записей, удаляется вместе с пустыми родителями
+		for parent := filepath.Dir(path); parent != dir; parent = filepath.Dir(parent) {
+			left, err := os.ReadDir(parent)
+			if err != nil || len(left) > 0 {
+				break
+			}
+			if err := os.Remove(parent); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isEntryMarkdown сообщает, что имя файла — имя файла записи, которое дает exportMarkdown
+func isEntryMarkdown(name string) bool {
+	id, rest, ok := strings.Cut(strings.TrimSuffix(name, ".md"), "-")
+	if !ok || rest == "" || !strings.HasSuffix(name, ".md") {
+		return false
+	}
+	if _, err := strconv.Atoi(id); err != nil {
+		return false
+	}
+	return rest == fileName(rest)
+}
+
+// entryMarkdown возвращает запись FAQ в виде Markdown-документа
+func entryMarkdown(entry FAQEntry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n%s\n", markdownInline(entry.Question), strings.TrimSpace(entry.Answer))
+	if entry.Category != "" || len(entry.Tags) > 0 {
+		b.WriteString("\n---\n\n")
+	}
+	if entry.Category != "" {
+		fmt.Fprintf(&b, "Категория: %s  \n", entry.Category)
+	}
+	if len(entry.Tags) > 0 {
+		fmt.Fprintf(&b, "Теги: %s\n", strings.Join(entry.Tags, ", "))
+	}
+	return b.String()
+}
+
+// markdownInline сводит текст в одну строку для заголовка или ссылки
+func markdownInline(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// fileName превращает текст в имя файла или каталога: буквы и цифры сохраняются,
+// остальное заменяется дефисами, длина ограничена
+func fileName(text string) string {
+	var b strings.Builder
+	dash := false
+	count := 0
+	for _, r := range strings.ToLower(text) {
+		if count >= 60 {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+			count++
+		} else if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+			count++
+		}
+	}
+	name := strings.Trim(b.String(), "-")
+	if name == "" {
+		return "_"
+	}
+	return name
+}
+
+// showExportDialog предлагает выбрать, что и в каком формате выгрузить, и куда сохранить файл.
+// Markdown доступен только для FAQ и сохраняется в выбранный каталог
+func showExportDialog(db *sql.DB, faq *FAQService, favorites *FavoritesStore, w fyne.Window) {
+	kindTitles := make([]string, 0, len(exportKinds))
+	for _, kind := range exportKinds {
+		kindTitles = append(kindTitles, kind.title)
+	}
+	kindSelect := widget.NewSelect(kindTitles, nil)
+	kindSelect.Selected = kindTitles[0]
+
+	formats := []string{"JSON", "CSV", "Markdown"}
+	formatSelect := widget.NewSelect(formats, nil)
+	formatSelect.Selected = formats[0]
+
+	selectedKind := func() string {
+		for _, kind := range exportKinds {
+			if kind.title == kindSelect.Selected {
+				return kind.value
+			}
+		}
+		return ExportFAQ
+	}
+
+	save := func() {
+		kind := selectedKind()
+		if formatSelect.Selected == "Markdown" {
+			if kind != ExportFAQ {
+				dialog.ShowInformation("Экспорт", "В Markdown выгружается только FAQ", w)
+				return
+			}
+			folder := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				if uri == nil {
+					return
+				}
+				n, err := exportMarkdown(uri.Path(), faq.Entries())
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				dialog.ShowInformation("Экспорт завершен", fmt.Sprintf("Записано записей: %d", n), w)
+			}, w)
+			folder.Show()
+			return
+		}
+
+		format := strings.ToLower(formatSelect.Selected)
+		file := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+
+			table, err := exportTable(kind, db, faq, favorites)
+			if err == nil {
+				err = writeExport(writer, table, format)
+			}
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("ошибка экспорта: %w", err), w)
+				return
+			}
+			dialog.ShowInformation("Экспорт завершен", fmt.Sprintf("Выгружено записей: %d", len(table.Rows)), w)
+		}, w)
+		file.SetFileName(kind + "." + format)
+		file.SetFilter(storage.NewExtensionFileFilter([]string{"." + format}))
+		file.Show()
+	}
+
+	dialog.ShowCustomConfirm("Экспорт", "Выгрузить", "Отмена",
+		container.NewVBox(
+			widget.NewForm(
+				widget.NewFormItem("Данные", kindSelect),
+				widget.NewFormItem("Формат", formatSelect),
+			),
+		),
+		func(ok bool) {
+			if ok {
+				save()
+			}
+		}, w)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	entries := []FAQEntry{
+		{Question: "Как настроить VPN?", Answer: "Откройте клиент VPN,\nвведите логин; пароль", Category: "Сеть/VPN", Tags: []string{"vpn", "удаленка"}},
+		{Question: "Не печатает принтер", Answer: "Проверьте \"очередь\" печати", Category: "Оборудование"},
+		{Question: "Нет звука", Answer: "Проверьте драйвер\tи громкость", Tags: []string{"звук"}},
+	}
+
+	for _, format := range []string{FormatCSV, FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			source := newTestFAQ(t, entries...)
+			var buf bytes.Buffer
+			if err := writeExport(&buf, faqExportTable(source.Entries()), format); err != nil {
+				t.Fatal(err)
+			}
+			data := buf.Bytes()
+
+			table, err := readImport(bytes.NewReader(data), format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			target := newTestFAQ(t)
+			rows := prepareImport(table, guessMapping(table.Columns), target)
+			result, err := target.Import(rows, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Created != len(entries) {
+				t.Fatalf("добавлено записей: %d, ожидалось %d", result.Created, len(entries))
+			}
+
+			want := source.Entries()
+			got := target.Entries()
+			if len(got) != len(want) {
+				t.Fatalf("записей после импорта: %d, ожидалось %d", len(got), len(want))
+			}
+			for i := range want {
+				want[i].ID, got[i].ID = 0, 0
+				if !reflect.DeepEqual(got[i], want[i]) {
+					t.Errorf("запись %d: %+v, ожидалась %+v", i, got[i], want[i])
+				}
+			}
+
+			// Повторный импорт того же файла ничего не добавляет
+			table, err = readImport(bytes.NewReader(data), format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			summary := summarizeImport(prepareImport(table, guessMapping(table.Columns), target))
+			if summary[ImportDuplicate] != len(entries) {
+				t.Errorf("повторный импорт: %v, ожидалось %d дубликатов", summary, len(entries))
+			}
+		})
+	}
+}
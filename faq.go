@@ -0,0 +1,539 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/blevesearch/bleve/v2"
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// ErrFAQNotFound возвращается, если записи с таким идентификатором нет
+var ErrFAQNotFound = errors.New("запись FAQ не найдена")
+
+// FAQService — единственное место, где меняются записи FAQ.
+// Каждое изменение проходит через SQLite, индекс Bleve и кэш в памяти:
+// если индекс не удалось обновить, транзакция в SQLite откатывается
+type FAQService struct {
+	db     *sql.DB
+	index  bleve.Index
+	editor string // имя оператора для истории правок
+
+	mu         sync.RWMutex
+	entries    map[int]FAQEntry
+	categories map[int]categoryNode
+	listeners  []func()
+}
+
+// faqDocument — запись FAQ в том виде, в каком она хранится в индексе Bleve
+type faqDocument struct {
+	Question string
+	Answer   string
+	Category []string // путь категории вместе с родителями, для фильтра и подсчета
+	Tags     []string
+}
+
+// document готовит запись к индексации
+func (e FAQEntry) document() faqDocument {
+	return faqDocument{
+		Question: e.Question,
+		Answer:   e.Answer,
+		Category: categoryAncestors(e.Category),
+		Tags:     e.Tags,
+	}
+}
+
+// NewFAQService загружает записи FAQ из базы в кэш.
+// editor записывается в историю правок как автор изменений
+func NewFAQService(db *sql.DB, idx bleve.Index, editor string) (*FAQService, error) {
+	entries, err := loadFAQEntries(db)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := loadCategories(db)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &FAQService{
+		db:         db,
+		index:      idx,
+		editor:     editor,
+		entries:    make(map[int]FAQEntry, len(entries)),
+		categories: categories,
+	}
+	for _, entry := range entries {
+		s.entries[entry.ID] = entry
+	}
+	return s, nil
+}
+
+// OnChange подписывает функцию на любые изменения FAQ; вызывается вне потока UI
+func (s *FAQService) OnChange(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// Entries возвращает снимок всех записей, упорядоченный по идентификатору
+func (s *FAQService) Entries() []FAQEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]FAQEntry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+	return entries
+}
+
+// Get возвращает запись из кэша
+func (s *FAQService) Get(id int) (FAQEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry, ok := s.entries[id]
+	return entry, ok
+}
+
+// Categories возвращает пути всех категорий по алфавиту, включая категории без записей
+func (s *FAQService) Categories() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	paths := make([]string, 0, len(s.categories))
+	for id := range s.categories {
+		paths = append(paths, categoryPath(s.categories, sql.NullInt64{Int64: int64(id), Valid: true}))
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Tags возвращает все теги, которые есть у записей, по алфавиту
+func (s *FAQService) Tags() []string {
+	var tags []string
+	for _, entry := range s.Entries() {
+		tags = append(tags, entry.Tags...)
+	}
+	return normalizeTags(tags)
+}
+
+// FindExact ищет запись с точно таким же вопросом без учета регистра и пробелов по краям
+func (s *FAQService) FindExact(question string) (FAQEntry, bool) {
+	question = strings.TrimSpace(question)
+	for _, entry := range s.Entries() {
+		if strings.EqualFold(strings.TrimSpace(entry.Question), question) {
+			return entry, true
+		}
+	}
+	return FAQEntry{}, false
+}
+
+// Create добавляет запись в базу и индекс; ID записи назначает база
+func (s *FAQService) Create(entry FAQEntry) (FAQEntry, error) {
+	entry = normalizeEntry(entry)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return FAQEntry{}, err
+	}
+	defer tx.Rollback()
+
+	categoryID, err := resolveCategory(tx, entry.Category)
+	if err != nil {
+		return FAQEntry{}, err
+	}
+	res, err := tx.Exec("INSERT INTO faq (question, answer, category_id) VALUES (?, ?, ?)", entry.Question, entry.Answer, categoryID)
+	if err != nil {
+		return FAQEntry{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return FAQEntry{}, err
+	}
+	entry.ID = int(id)
+	if err := saveTags(tx, entry.ID, entry.Tags); err != nil {
+		return FAQEntry{}, err
+	}
+	if err := saveRevision(tx, FAQEntry{}, entry, s.editor); err != nil {
+		return FAQEntry{}, err
+	}
+
+	if err := s.index.Index(docID(entry.ID), entry.document()); err != nil {
+		return FAQEntry{}, fmt.Errorf("ошибка индексации: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		s.index.Delete(docID(entry.ID))
+		return FAQEntry{}, err
+	}
+
+	s.store(entry)
+	return entry, nil
+}
+
+// Update меняет вопрос, ответ, категорию и теги записи в базе и индексе
+func (s *FAQService) Update(entry FAQEntry) error {
+	entry = normalizeEntry(entry)
+	old, ok := s.Get(entry.ID)
+	if !ok {
+		return ErrFAQNotFound
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	categoryID, err := resolveCategory(tx, entry.Category)
+	if err != nil {
+		return err
+	}
+	res, err := tx.Exec("UPDATE faq SET question = ?, answer = ?, category_id = ? WHERE id = ? AND deleted_at IS NULL", entry.Question, entry.Answer, categoryID, entry.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrFAQNotFound
+	}
+	if err := saveTags(tx, entry.ID, entry.Tags); err != nil {
+		return err
+	}
+	if err := saveRevision(tx, old, entry, s.editor); err != nil {
+		return err
+	}
+
+	if err := s.index.Index(docID(entry.ID), entry.document()); err != nil {
+		return fmt.Errorf("ошибка индексации: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		s.index.Index(docID(entry.ID), old.document())
+		return err
+	}
+
+	s.store(entry)
+	return nil
+}
+
+// Delete переносит запись в корзину и убирает ее из индекса
+func (s *FAQService) Delete(id int) error {
+	old, ok := s.Get(id)
+	if !ok {
+		return ErrFAQNotFound
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE faq SET deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL", id); err != nil {
+		return err
+	}
+	if err := s.index.Delete(docID(id)); err != nil {
+		return fmt.Errorf("ошибка удаления из индекса: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		s.index.Index(docID(id), old.document())
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.entries, id)
+	s.mu.Unlock()
+	s.notify()
+	return nil
+}
+
+// Undelete возвращает запись из корзины в базу и индекс
+func (s *FAQService) Undelete(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE faq SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrFAQNotFound
+	}
+	entry, err := s.loadEntry(tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.index.Index(docID(id), entry.document()); err != nil {
+		return fmt.Errorf("ошибка индексации: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		s.index.Delete(docID(id))
+		return err
+	}
+
+	s.store(entry)
+	return nil
+}
+
+// loadEntry читает одну запись из базы вместе с категорией и тегами
+func (s *FAQService) loadEntry(tx *sql.Tx, id int) (FAQEntry, error) {
+	entry := FAQEntry{ID: id}
+	var categoryID sql.NullInt64
+	err := tx.QueryRow("SELECT question, answer, category_id FROM faq WHERE id = ?", id).
+		Scan(&entry.Question, &entry.Answer, &categoryID)
+	if err == sql.ErrNoRows {
+		return entry, ErrFAQNotFound
+	}
+	if err != nil {
+		return entry, err
+	}
+
+	s.mu.RLock()
+	entry.Category = categoryPath(s.categories, categoryID)
+	s.mu.RUnlock()
+
+	rows, err := tx.Query("SELECT tag FROM faq_tags WHERE faq_id = ? ORDER BY tag", id)
+	if err != nil {
+		return entry, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return entry, err
+		}
+		entry.Tags = append(entry.Tags, tag)
+	}
+	return entry, rows.Err()
+}
+
+// CheckIndex сверяет индекс Bleve с базой: переиндексирует отсутствующие
+// и устаревшие документы, удаляет документы без записи в базе
+func (s *FAQService) CheckIndex() (reindexed, removed int, err error) {
+	entries := s.Entries()
+	live := make(map[string]bool, len(entries))
+
+	batch := s.index.NewBatch()
+	for _, entry := range entries {
+		id := docID(entry.ID)
+		live[id] = true
+
+		doc, err := s.index.Document(id)
+		if err != nil {
+			return 0, 0, err
+		}
+		if doc != nil && indexedMatches(doc, entry) {
+			continue
+		}
+		if err := batch.Index(id, entry.document()); err != nil {
+			return 0, 0, err
+		}
+		reindexed++
+	}
+
+	ids, err := s.indexedIDs()
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, id := range ids {
+		if !live[id] {
+			batch.Delete(id)
+			removed++
+		}
+	}
+
+	if batch.Size() > 0 {
+		if err := s.index.Batch(batch); err != nil {
+			return 0, 0, err
+		}
+	}
+	return reindexed, removed, nil
+}
+
+// Rebuild перечитывает все записи из базы и строит индекс заново: документы удаляются
+// и добавляются из базы одним пакетом. Нужен, когда база заменена целиком, например восстановлена из копии.
+// Возвращает число проиндексированных записей
+func (s *FAQService) Rebuild() (int, error) {
+	entries, err := loadFAQEntries(s.db)
+	if err != nil {
+		return 0, err
+	}
+	categories, err := loadCategories(s.db)
+	if err != nil {
+		return 0, err
+	}
+	ids, err := s.indexedIDs()
+	if err != nil {
+		return 0, err
+	}
+
+	batch := s.index.NewBatch()
+	for _, id := range ids {
+		batch.Delete(id)
+	}
+	for _, entry := range entries {
+		if err := batch.Index(docID(entry.ID), entry.document()); err != nil {
+			return 0, err
+		}
+	}
+	if err := s.index.Batch(batch); err != nil {
+		return 0, fmt.Errorf("ошибка индексации: %w", err)
+	}
+
+	s.mu.Lock()
+	s.entries = make(map[int]FAQEntry, len(entries))
+	for _, entry := range entries {
+		s.entries[entry.ID] = entry
+	}
+	s.categories = categories
+	s.mu.Unlock()
+	s.notify()
+	return len(entries), nil
+}
+
+// indexedIDs возвращает идентификаторы всех документов индекса
+func (s *FAQService) indexedIDs() ([]string, error) {
+	count, err := s.index.DocCount()
+	if err != nil {
+		return nil, err
+	}
+	req := bleve.NewSearchRequest(bleve.NewMatchAllQuery())
+	req.Size = int(count)
+	res, err := s.index.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(res.Hits))
+	for _, hit := range res.Hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids, nil
+}
+
+// store обновляет кэш после изменения записей. Дерево категорий перечитывается,
+// потому что при сохранении могли появиться новые категории
+func (s *FAQService) store(entries ...FAQEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	categories, err := loadCategories(s.db)
+	if err != nil {
+		log.Printf("Ошибка загрузки категорий: %v", err)
+	}
+
+	s.mu.Lock()
+	for _, entry := range entries {
+		s.entries[entry.ID] = entry
+	}
+	if err == nil {
+		s.categories = categories
+	}
+	s.mu.Unlock()
+	s.notify()
+}
+
+func (s *FAQService) notify() {
+	s.mu.RLock()
+	listeners := append([]func(){}, s.listeners...)
+	s.mu.RUnlock()
+	for _, fn := range listeners {
+		fn()
+	}
+}
+
+// indexedMatches сравнивает сохраненные в индексе поля с записью из базы
+func indexedMatches(doc index.Document, entry FAQEntry) bool {
+	var question, answer string
+	var categories, tags []string
+	doc.VisitFields(func(field index.Field) {
+		switch field.Name() {
+		case "Question":
+			question = string(field.Value())
+		case "Answer":
+			answer = string(field.Value())
+		case "Category":
+			categories = append(categories, string(field.Value()))
+		case "Tags":
+			tags = append(tags, string(field.Value()))
+		}
+	})
+	want := entry.document()
+	return question == want.Question && answer == want.Answer &&
+		slices.Equal(categories, want.Category) && slices.Equal(tags, want.Tags)
+}
+
+// normalizeEntry приводит категорию и теги записи к каноническому виду
+func normalizeEntry(entry FAQEntry) FAQEntry {
+	entry.Category = normalizeCategory(entry.Category)
+	entry.Tags = normalizeTags(entry.Tags)
+	return entry
+}
+
+// docID — идентификатор документа записи FAQ в индексе Bleve
+func docID(id int) string {
+	return strconv.Itoa(id)
+}
+
+// loadFAQEntries загружает все записи FAQ вне корзины вместе с категориями и тегами
+func loadFAQEntries(db *sql.DB) ([]FAQEntry, error) {
+	categories, err := loadCategories(db)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := loadTags(db)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query("SELECT id, question, answer, category_id FROM faq WHERE deleted_at IS NULL")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []FAQEntry
+	for rows.Next() {
+		var entry FAQEntry
+		var categoryID sql.NullInt64
+		if err := rows.Scan(&entry.ID, &entry.Question, &entry.Answer, &categoryID); err != nil {
+			return nil, err
+		}
+		entry.Category = categoryPath(categories, categoryID)
+		entry.Tags = tags[entry.ID]
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// loadTags загружает теги всех записей, сгруппированные по записи
+func loadTags(db *sql.DB) (map[int][]string, error) {
+	rows, err := db.Query("SELECT faq_id, tag FROM faq_tags ORDER BY faq_id, tag")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make(map[int][]string)
+	for rows.Next() {
+		var id int
+		var tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			return nil, err
+		}
+		tags[id] = append(tags[id], tag)
+	}
+	return tags, rows.Err()
+}
@@ -0,0 +1,595 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// FavoriteRef — запись, на которую ссылается избранное: FAQ или ответ из истории.
+// Нулевое значение — ссылки нет, хранится только снимок текста
+type FavoriteRef struct {
+	FAQID     int
+	HistoryID int
+}
+
+// Favorite — ответ в избранном
+type Favorite struct {
+	ID  int
+	Ref FavoriteRef
+	// Question и Answer — текст на момент добавления; показываются, если исходная запись удалена
+	Question  string
+	Answer    string
+	FolderID  int // 0 — вне папок
+	Note      string
+	Position  int
+	CreatedAt string
+}
+
+// FavoriteFolder — папка избранного
+type FavoriteFolder struct {
+	ID   int
+	Name string
+}
+
+// ErrFavoriteExists возвращается при повторном добавлении той же записи
+var ErrFavoriteExists = errors.New("ответ уже в избранном")
+
+// ErrFavoriteNotFound возвращается, когда удалять из избранного нечего
+var ErrFavoriteNotFound = errors.New("ответа нет в избранном")
+
+// FavoritesStore работает с избранным и оповещает подписчиков о любых изменениях
+type FavoritesStore struct {
+	db  *sql.DB
+	faq *FAQService
+
+	mu        sync.Mutex
+	listeners []func()
+}
+
+// NewFavoritesStore создает хранилище избранного
+func NewFavoritesStore(db *sql.DB, faq *FAQService) *FavoritesStore {
+	return &FavoritesStore{db: db, faq: faq}
+}
+
+// OnChange подписывает функцию на изменения избранного и папок
+func (s *FavoritesStore) OnChange(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+func (s *FavoritesStore) changed() {
+	s.mu.Lock()
+	listeners := append([]func(){}, s.listeners...)
+	s.mu.Unlock()
+	for _, fn := range listeners {
+		fn()
+	}
+}
+
+// Add добавляет ответ в избранное наверх списка. question и answer сохраняются как снимок
+func (s *FavoritesStore) Add(ref FavoriteRef, question, answer string) error {
+	if ref != (FavoriteRef{}) {
+		var exists int
+		err := s.db.QueryRow(`
+			SELECT COUNT(*) FROM favorites
+			WHERE deleted_at IS NULL AND IFNULL(faq_id, 0) = ? AND IFNULL(history_id, 0) = ?
+		`, ref.FAQID, ref.HistoryID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists > 0 {
+			return ErrFavoriteExists
+		}
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO favorites (question, answer, faq_id, history_id, position)
+		VALUES (?, ?, ?, ?, (SELECT IFNULL(MAX(position), 0) + 1 FROM favorites))
+	`, question, answer, nullID(ref.FAQID), nullID(ref.HistoryID))
+	if err != nil {
+		return err
+	}
+	s.changed()
+	return nil
+}
+
+// RemoveRef переносит в корзину избранное, ссылающееся на запись.
+// Если такого избранного нет, возвращает ErrFavoriteNotFound
+func (s *FavoritesStore) RemoveRef(ref FavoriteRef) error {
+	if ref == (FavoriteRef{}) {
+		return ErrFavoriteNotFound
+	}
+	res, err := s.db.Exec(`
+		UPDATE favorites SET deleted_at = CURRENT_TIMESTAMP
+		WHERE deleted_at IS NULL AND IFNULL(faq_id, 0) = ? AND IFNULL(history_id, 0) = ?
+	`, ref.FAQID, ref.HistoryID)
+	if err != nil {
+		return err
+	}
+	removed, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrFavoriteNotFound
+	}
+	s.changed()
+	return nil
+}
+
+// Remove переносит ответ из избранного в корзину
+func (s *FavoritesStore) Remove(id int) error {
+	if err := trashRow(s.db, TrashFavorites, id); err != nil {
+		return err
+	}
+	s.changed()
+	return nil
+}
+
+// List возвращает избранное из папки в порядке, заданном оператором.
+// folderID < 0 — все папки, 0 — ответы вне папок
+func (s *FavoritesStore) List(folderID int) ([]Favorite, error) {
+	query := `
+		SELECT f.id, IFNULL(f.faq_id, 0), IFNULL(f.history_id, 0),
+			IFNULL(h.question, IFNULL(f.question, '')), IFNULL(h.answer, IFNULL(f.answer, '')),
+			IFNULL(f.folder_id, 0), f.note, f.position, f.created_at
+		FROM favorites f
+		LEFT JOIN history h ON h.id = f.history_id AND h.deleted_at IS NULL
+		WHERE f.deleted_at IS NULL`
+	var args []any
+	if folderID >= 0 {
+		query += " AND IFNULL(f.folder_id, 0) = ?"
+		args = append(args, folderID)
+	}
+	query += " ORDER BY f.position DESC, f.id DESC"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var favorites []Favorite
+	for rows.Next() {
+		var f Favorite
+		err := rows.Scan(&f.ID, &f.Ref.FAQID, &f.Ref.HistoryID, &f.Question, &f.Answer,
+			&f.FolderID, &f.Note, &f.Position, &f.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		// Запись FAQ показываем в актуальной редакции
+		if entry, ok := s.faq.Get(f.Ref.FAQID); ok {
+			f.Question, f.Answer = entry.Question, entry.Answer
+		}
+		favorites = append(favorites, f)
+	}
+	return favorites, rows.Err()
+}
+
+// source описывает, откуда взят текст избранного
+func (s *FavoritesStore) source(f Favorite) string {
+	switch {
+	case f.Ref.FAQID != 0:
+		if _, ok := s.faq.Get(f.Ref.FAQID); ok {
+			return fmt.Sprintf("FAQ #%d", f.Ref.FAQID)
+		}
+		return fmt.Sprintf("FAQ #%d удалена, показан сохраненный текст", f.Ref.FAQID)
+	case f.Ref.HistoryID != 0:
+		return fmt.Sprintf("Ответ из истории #%d", f.Ref.HistoryID)
+	default:
+		return "Сохраненный текст"
+	}
+}
+
+// SetNote сохраняет заметку оператора
+func (s *FavoritesStore) SetNote(id int, note string) error {
+	if _, err := s.db.Exec("UPDATE favorites SET note = ? WHERE id = ?", strings.TrimSpace(note), id); err != nil {
+		return err
+	}
+	s.changed()
+	return nil
+}
+
+// Move переносит ответ в папку; 0 — убрать из папки
+func (s *FavoritesStore) Move(id, folderID int) error {
+	if _, err := s.db.Exec("UPDATE favorites SET folder_id = ? WHERE id = ?", nullID(folderID), id); err != nil {
+		return err
+	}
+	s.changed()
+	return nil
+}
+
+// Reorder задает новый порядок для ответов ids (сверху вниз).
+// Ответы получают те же значения position, что были у них вместе, поэтому
+// порядок в других папках не меняется
+func (s *FavoritesStore) Reorder(ids []int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	positions := make([]int, 0, len(ids))
+	for _, id := range ids {
+		var position int
+		if err := tx.QueryRow("SELECT position FROM favorites WHERE id = ?", id).Scan(&position); err != nil {
+			return err
+		}
+		positions = append(positions, position)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(positions)))
+	for i, id := range ids {
+		if _, err := tx.Exec("UPDATE favorites SET position = ? WHERE id = ?", positions[i], id); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.changed()
+	return nil
+}
+
+// Folders возвращает папки по алфавиту
+func (s *FavoritesStore) Folders() ([]FavoriteFolder, error) {
+	rows, err := s.db.Query("SELECT id, name FROM favorite_folders ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var folders []FavoriteFolder
+	for rows.Next() {
+		var folder FavoriteFolder
+		if err := rows.Scan(&folder.ID, &folder.Name); err != nil {
+			return nil, err
+		}
+		folders = append(folders, folder)
+	}
+	return folders, rows.Err()
+}
+
+// CreateFolder создает папку
+func (s *FavoritesStore) CreateFolder(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("введите название папки")
+	}
+	if _, err := s.db.Exec("INSERT INTO favorite_folders (name) VALUES (?)", name); err != nil {
+		return fmt.Errorf("ошибка создания папки: %w", err)
+	}
+	s.changed()
+	return nil
+}
+
+// RenameFolder меняет название папки
+func (s *FavoritesStore) RenameFolder(id int, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("введите название папки")
+	}
+	if _, err := s.db.Exec("UPDATE favorite_folders SET name = ? WHERE id = ?", name, id); err != nil {
+		return fmt.Errorf("ошибка переименования папки: %w", err)
+	}
+	s.changed()
+	return nil
+}
+
+// DeleteFolder удаляет папку; ответы из нее остаются в избранном вне папок
+func (s *FavoritesStore) DeleteFolder(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE favorites SET folder_id = NULL WHERE folder_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM favorite_folders WHERE id = ?", id); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.changed()
+	return nil
+}
+
+// nullID превращает отсутствующий идентификатор в NULL
+func nullID(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
+
+// dragHandle — значок, за который ответ перетаскивают вверх или вниз по списку.
+// onDrop получает смещение по вертикали от начала перетаскивания
+type dragHandle struct {
+	widget.Icon
+	dy     float32
+	onDrop func(dy float32)
+}
+
+func newDragHandle(onDrop func(dy float32)) *dragHandle {
+	h := &dragHandle{onDrop: onDrop}
+	h.Resource = theme.MenuIcon()
+	h.ExtendBaseWidget(h)
+	return h
+}
+
+func (h *dragHandle) Dragged(e *fyne.DragEvent) {
+	h.dy += e.Dragged.DY
+}
+
+func (h *dragHandle) DragEnd() {
+	dy := h.dy
+	h.dy = 0
+	if h.onDrop != nil {
+		h.onDrop(dy)
+	}
+}
+
+// createFavoritesTab создает вкладку "Избранное" с папками, заметками и ручной сортировкой.
+// Вкладка перерисовывается сама при изменении избранного или записей FAQ
+func createFavoritesTab(favs *FavoritesStore, w fyne.Window) fyne.CanvasObject {
+	const (
+		allFolders = "Все папки"
+		noFolder   = "Без папки"
+	)
+
+	var folders []FavoriteFolder
+	folderSelect := widget.NewSelect(nil, nil)
+	list := container.NewVBox()
+
+	folderByName := func(name string) (FavoriteFolder, bool) {
+		for _, folder := range folders {
+			if folder.Name == name {
+				return folder, true
+			}
+		}
+		return FavoriteFolder{}, false
+	}
+	folderName := func(id int) string {
+		for _, folder := range folders {
+			if folder.ID == id {
+				return folder.Name
+			}
+		}
+		return noFolder
+	}
+	selectedFolder := func() int {
+		if folder, ok := folderByName(folderSelect.Selected); ok {
+			return folder.ID
+		}
+		if folderSelect.Selected == noFolder {
+			return 0
+		}
+		return -1
+	}
+
+	// Несохраненные заметки по ID ответа: список перестраивается при любом изменении избранного
+	// или FAQ, и набранный текст не должен теряться
+	noteDrafts := make(map[int]string)
+
+	var refresh func()
+	refresh = func() {
+		var err error
+		folders, err = favs.Folders()
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		names := []string{noFolder}
+		for _, folder := range folders {
+			names = append(names, folder.Name)
+		}
+
+		// Selected меняется напрямую, чтобы не вызвать OnChanged повторно
+		folderSelect.Options = append([]string{allFolders}, names...)
+		if folderSelect.Selected != noFolder {
+			if _, ok := folderByName(folderSelect.Selected); !ok {
+				folderSelect.Selected = allFolders
+			}
+		}
+		folderSelect.Refresh()
+
+		items, err := favs.List(selectedFolder())
+		list.Objects = nil
+		if err != nil {
+			list.Add(widget.NewLabel("Ошибка загрузки избранного: " + err.Error()))
+			list.Refresh()
+			return
+		}
+		if len(items) == 0 {
+			list.Add(widget.NewLabel("В избранном пока ничего нет"))
+		}
+
+		// drop переставляет ответ i на место, куда его отпустили
+		drop := func(i int, dy float32) {
+			cards := list.Objects
+			center := cards[i].Position().Y + dy + cards[i].Size().Height/2
+			j := 0
+			for k, card := range cards {
+				if k != i && card.Position().Y+card.Size().Height/2 < center {
+					j++
+				}
+			}
+			if j == i {
+				return
+			}
+			ids := make([]int, 0, len(items))
+			for k, fav := range items {
+				if k != i {
+					ids = append(ids, fav.ID)
+				}
+			}
+			ids = append(ids[:j], append([]int{items[i].ID}, ids[j:]...)...)
+			if err := favs.Reorder(ids); err != nil {
+				dialog.ShowError(err, w)
+			}
+		}
+
+		for i, fav := range items {
+			answerLabel := widget.NewLabel(fav.Answer)
+			answerLabel.Wrapping = fyne.TextWrapWord
+			sourceLabel := widget.NewLabelWithStyle(favs.source(fav), fyne.TextAlignLeading, fyne.TextStyle{Italic: true})
+
+			note := widget.NewMultiLineEntry()
+			note.SetPlaceHolder("Заметка оператора")
+			if draft, ok := noteDrafts[fav.ID]; ok {
+				note.SetText(draft)
+			} else {
+				note.SetText(fav.Note)
+			}
+			note.Wrapping = fyne.TextWrapWord
+			note.SetMinRowsVisible(2)
+			note.OnChanged = func(text string) {
+				if text == fav.Note {
+					delete(noteDrafts, fav.ID)
+				} else {
+					noteDrafts[fav.ID] = text
+				}
+			}
+			saveNoteBtn := widget.NewButtonWithIcon("Сохранить заметку", theme.DocumentSaveIcon(), func() {
+				if err := favs.SetNote(fav.ID, note.Text); err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				delete(noteDrafts, fav.ID)
+			})
+
+			moveSelect := widget.NewSelect(names, nil)
+			moveSelect.Selected = folderName(fav.FolderID)
+			moveSelect.OnChanged = func(name string) {
+				folder, _ := folderByName(name)
+				if err := favs.Move(fav.ID, folder.ID); err != nil {
+					dialog.ShowError(err, w)
+				}
+			}
+
+			copyBtn := widget.NewButtonWithIcon("Копировать", theme.ContentCopyIcon(), func() {
+				w.Clipboard().SetContent(fav.Answer)
+				dialog.ShowInformation("Успех", "Ответ скопирован в буфер обмена", w)
+			})
+			copyBtn.Importance = widget.HighImportance
+
+			deleteBtn := widget.NewButtonWithIcon("Удалить", theme.DeleteIcon(), func() {
+				if err := favs.Remove(fav.ID); err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				dialog.ShowInformation("Успех", "Ответ перемещен в корзину", w)
+			})
+			deleteBtn.Importance = widget.HighImportance
+
+			content := container.NewVBox(
+				widget.NewLabelWithStyle(fav.Question, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				answerLabel,
+				sourceLabel,
+				note,
+				container.NewHBox(
+					saveNoteBtn,
+					layout.NewSpacer(),
+					widget.NewLabel("Папка:"),
+					moveSelect,
+					copyBtn,
+					deleteBtn,
+				),
+			)
+			index := i
+			handle := newDragHandle(func(dy float32) {
+				drop(index, dy)
+			})
+			list.Add(widget.NewCard("", "", container.NewBorder(nil, nil, handle, nil, content)))
+		}
+		list.Refresh()
+	}
+	folderSelect.OnChanged = func(string) {
+		refresh()
+	}
+	folderSelect.Selected = allFolders
+	refresh()
+
+	favs.OnChange(func() {
+		fyne.Do(refresh)
+	})
+	favs.faq.OnChange(func() {
+		fyne.Do(refresh)
+	})
+
+	newFolderBtn := widget.NewButtonWithIcon("Новая папка", theme.FolderNewIcon(), func() {
+		name := widget.NewEntry()
+		dialog.ShowForm("Новая папка", "Создать", "Отмена", []*widget.FormItem{
+			widget.NewFormItem("Название", name),
+		}, func(ok bool) {
+			if ok {
+				if err := favs.CreateFolder(name.Text); err != nil {
+					dialog.ShowError(err, w)
+				}
+			}
+		}, w)
+	})
+
+	renameFolderBtn := widget.NewButtonWithIcon("Переименовать", theme.DocumentCreateIcon(), func() {
+		folder, ok := folderByName(folderSelect.Selected)
+		if !ok {
+			dialog.ShowInformation("Папка не выбрана", "Выберите папку в списке", w)
+			return
+		}
+		name := widget.NewEntry()
+		name.SetText(folder.Name)
+		dialog.ShowForm("Переименовать папку", "Сохранить", "Отмена", []*widget.FormItem{
+			widget.NewFormItem("Название", name),
+		}, func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := favs.RenameFolder(folder.ID, name.Text); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			folderSelect.Selected = strings.TrimSpace(name.Text)
+			refresh()
+		}, w)
+	})
+
+	deleteFolderBtn := widget.NewButtonWithIcon("Удалить папку", theme.DeleteIcon(), func() {
+		folder, ok := folderByName(folderSelect.Selected)
+		if !ok {
+			dialog.ShowInformation("Папка не выбрана", "Выберите папку в списке", w)
+			return
+		}
+		dialog.ShowConfirm("Подтверждение", fmt.Sprintf("Удалить папку «%s»? Ответы из нее останутся в избранном.", folder.Name), func(ok bool) {
+			if ok {
+				if err := favs.DeleteFolder(folder.ID); err != nil {
+					dialog.ShowError(err, w)
+				}
+			}
+		}, w)
+	})
+
+	scroll := container.NewScroll(list)
+	scroll.SetMinSize(fyne.NewSize(800, 600))
+	return container.NewBorder(
+		container.NewHBox(
+			widget.NewLabel("Папка:"),
+			folderSelect,
+			newFolderBtn,
+			renameFolderBtn,
+			deleteFolderBtn,
+		),
+		nil, nil, nil,
+		scroll,
+	)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Feedback — оценка ответа оператором
+type Feedback struct {
+	HistoryID int // запись истории с вопросом, на который дан ответ
+	FAQID     int // запись FAQ, из которой взят ответ; 0 — ответ модели
+	Helpful   bool
+	Comment   string
+}
+
+// FeedbackStats — сводка оценок записи FAQ
+type FeedbackStats struct {
+	Up   int
+	Down int
+}
+
+// describe возвращает сводку оценок одной строкой
+func (s FeedbackStats) describe() string {
+	return fmt.Sprintf("Полезно: %d · Не помогло: %d", s.Up, s.Down)
+}
+
+// FeedbackStore хранит оценки ответов и держит в памяти сводку по записям FAQ,
+// которую поиск использует при ранжировании
+type FeedbackStore struct {
+	db *sql.DB
+
+	mu    sync.RWMutex
+	stats map[int]FeedbackStats
+}
+
+// NewFeedbackStore создает хранилище оценок и загружает сводку
+func NewFeedbackStore(db *sql.DB) (*FeedbackStore, error) {
+	s := &FeedbackStore{db: db}
+	if err := s.load(); err != nil {
+		return nil, fmt.Errorf("ошибка загрузки оценок: %w", err)
+	}
+	return s, nil
+}
+
+func (s *FeedbackStore) load() error {
+	rows, err := s.db.Query(`
+		SELECT faq_id, SUM(helpful), SUM(1 - helpful)
+		FROM feedback
+		WHERE faq_id IS NOT NULL
+		GROUP BY faq_id
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	stats := make(map[int]FeedbackStats)
+	for rows.Next() {
+		var faqID int
+		var st FeedbackStats
+		if err := rows.Scan(&faqID, &st.Up, &st.Down); err != nil {
+			return err
+		}
+		stats[faqID] = st
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.stats = stats
+	s.mu.Unlock()
+	return nil
+}
+
+// Save записывает оценку. Повторная оценка того же ответа на тот же вопрос заменяет предыдущую.
+// Без записи истории повторные оценки не отличить от новых, поэтому такая оценка не сохраняется
+func (s *FeedbackStore) Save(fb Feedback) error {
+	if fb.HistoryID == 0 {
+		return errors.New("ответ не сохранен в историю, оценку не к чему привязать")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM feedback WHERE history_id = ? AND IFNULL(faq_id, 0) = ?", fb.HistoryID, fb.FAQID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("INSERT INTO feedback (history_id, faq_id, helpful, comment) VALUES (?, ?, ?, ?)",
+		fb.HistoryID, nullID(fb.FAQID), fb.Helpful, fb.Comment)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return s.load()
+}
+
+// Stats возвращает сводку оценок записи FAQ
+func (s *FeedbackStore) Stats(faqID int) FeedbackStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.stats[faqID]
+}
+
+// Demoted сообщает, что записью чаще недовольны, чем довольны
+func (s *FeedbackStore) Demoted(faqID int) bool {
+	st := s.Stats(faqID)
+	return st.Down > st.Up
+}
+
+// DemotedCount возвращает, сколько записей FAQ понижено из-за отрицательных оценок
+func (s *FeedbackStore) DemotedCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := 0
+	for _, st := range s.stats {
+		if st.Down > st.Up {
+			n++
+		}
+	}
+	return n
+}
+
+// penalty возвращает множитель оценки релевантности записи: 1 — оценки не влияют,
+// иначе каждый перевес отрицательных оценок над положительными снижает оценку.
+// weight задает силу снижения (search.feedback_weight)
+func (s *FeedbackStore) penalty(faqID int, weight float64) float64 {
+	st := s.Stats(faqID)
+	if st.Down <= st.Up {
+		return 1
+	}
+	return 1 / (1 + weight*float64(st.Down-st.Up))
+}
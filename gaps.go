@@ -0,0 +1,261 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// gapSimilarity — доля общих слов, начиная с которой вопросы попадают в одну группу
+const gapSimilarity = 0.5
+
+// GapQuestion — вопрос, на который в базе не нашлось подходящего ответа
+// или ответ на который оператор оценил как бесполезный
+type GapQuestion struct {
+	HistoryID int
+	Question  string
+	Answer    string // ответ модели или записи, которой оператор остался недоволен
+	Date      string
+	Negative  bool // есть отрицательная оценка
+}
+
+// GapCluster — группа похожих вопросов без хорошего ответа в базе
+type GapCluster struct {
+	Questions []GapQuestion // начиная с последнего заданного
+	LastAsked string
+	Negative  int // сколько вопросов получили отрицательную оценку
+}
+
+// Title возвращает формулировку, которую задавали чаще всего; при равенстве — последнюю
+func (c GapCluster) Title() string {
+	counts := make(map[string]int)
+	spellings := make(map[string]string) // последнее написание каждой формулировки
+	best, bestCount := "", 0
+	for _, q := range c.Questions {
+		key := strings.ToLower(strings.TrimSpace(q.Question))
+		if _, ok := spellings[key]; !ok {
+			spellings[key] = q.Question
+		}
+		counts[key]++
+		if counts[key] > bestCount {
+			best, bestCount = spellings[key], counts[key]
+		}
+	}
+	return strings.TrimSpace(best)
+}
+
+// historyIDs возвращает записи истории, которые закрывает ответ на группу
+func (c GapCluster) historyIDs() []int {
+	ids := make([]int, 0, len(c.Questions))
+	for _, q := range c.Questions {
+		ids = append(ids, q.HistoryID)
+	}
+	return ids
+}
+
+// loadGapQuestions загружает вопросы, ушедшие к модели, вопросы, где оператор не выбрал ни одну
+// из найденных записей, и вопросы с отрицательной оценкой, кроме тех, по которым запись FAQ уже написана
+func loadGapQuestions(db *sql.DB) ([]GapQuestion, error) {
+	rows, err := db.Query(`
+		SELECT h.id, h.question, h.answer, h.date,
+			EXISTS (SELECT 1 FROM feedback f WHERE f.history_id = h.id AND f.helpful = 0)
+		FROM history h
+		WHERE h.deleted_at IS NULL
+			AND h.promoted_faq_id IS NULL
+			AND h.resolved_faq_id IS NULL
+			AND (h.source = ?
+				OR h.source = ? AND h.answer = ''
+				OR EXISTS (SELECT 1 FROM feedback f WHERE f.history_id = h.id AND f.helpful = 0))
+		ORDER BY h.date DESC, h.id DESC
+	`, HistorySourceLLM, HistorySourceFAQ)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var questions []GapQuestion
+	for rows.Next() {
+		var q GapQuestion
+		if err := rows.Scan(&q.HistoryID, &q.Question, &q.Answer, &q.Date, &q.Negative); err != nil {
+			return nil, err
+		}
+		questions = append(questions, q)
+	}
+	return questions, rows.Err()
+}
+
+// questionWords возвращает значимые слова вопроса. Слова обрезаются до пяти букв,
+// чтобы разные формы одного слова ("принтер", "принтера") совпадали
+func questionWords(question string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(question), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(word)
+		if len(runes) < 3 {
+			continue
+		}
+		if len(runes) > 5 {
+			runes = runes[:5]
+		}
+		words[string(runes)] = true
+	}
+	return words
+}
+
+// wordSimilarity — коэффициент Жаккара: доля общих слов среди всех слов двух вопросов
+func wordSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for word := range a {
+		if b[word] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+// clusterGaps объединяет похожие вопросы в группы. Вопрос попадает в группу,
+// с первым вопросом которой у него больше всего общих слов, если их доля не ниже gapSimilarity.
+// Группы отсортированы по числу вопросов, затем по дате последнего
+func clusterGaps(questions []GapQuestion) []GapCluster {
+	var clusters []GapCluster
+	var heads []map[string]bool
+	for _, q := range questions {
+		words := questionWords(q.Question)
+		best, bestSimilarity := -1, 0.0
+		for i, head := range heads {
+			if sim := wordSimilarity(words, head); sim >= gapSimilarity && sim > bestSimilarity {
+				best, bestSimilarity = i, sim
+			}
+		}
+		if best < 0 {
+			clusters = append(clusters, GapCluster{})
+			heads = append(heads, words)
+			best = len(clusters) - 1
+		}
+
+		c := &clusters[best]
+		c.Questions = append(c.Questions, q)
+		if q.Date > c.LastAsked {
+			c.LastAsked = q.Date
+		}
+		if q.Negative {
+			c.Negative++
+		}
+	}
+
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if len(clusters[i].Questions) != len(clusters[j].Questions) {
+			return len(clusters[i].Questions) > len(clusters[j].Questions)
+		}
+		return clusters[i].LastAsked > clusters[j].LastAsked
+	})
+	return clusters
+}
+
+// resolveGap добавляет в базу запись, отвечающую на группу вопросов, и убирает их из списка пробелов
+func resolveGap(db *sql.DB, faq *FAQService, cluster GapCluster, entry FAQEntry) (FAQEntry, error) {
+	created, err := faq.Create(entry)
+	if err != nil {
+		return FAQEntry{}, err
+	}
+
+	ids := cluster.historyIDs()
+	args := []any{created.ID}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	_, err = db.Exec("UPDATE history SET resolved_faq_id = ? WHERE id IN ("+placeholders+")", args...)
+	if err != nil {
+		return created, fmt.Errorf("запись FAQ #%d создана, но вопросы не отмечены как закрытые: %w", created.ID, err)
+	}
+	return created, nil
+}
+
+// createGapsTab создает вкладку "Пробелы" с группами вопросов, на которые в базе нет хорошего ответа.
+// Возвращает также функцию, перечитывающую список
+func createGapsTab(db *sql.DB, faq *FAQService, w fyne.Window) (fyne.CanvasObject, func()) {
+	list := container.NewVBox()
+	summary := widget.NewLabel("")
+
+	var refresh func()
+	refresh = func() {
+		list.Objects = nil
+		questions, err := loadGapQuestions(db)
+		if err != nil {
+			summary.SetText("Ошибка загрузки вопросов: " + err.Error())
+			list.Refresh()
+			return
+		}
+		clusters := clusterGaps(questions)
+		summary.SetText(fmt.Sprintf("Вопросов без ответа в базе: %d · групп: %d", len(questions), len(clusters)))
+		if len(clusters) == 0 {
+			list.Add(widget.NewLabel("Пробелов нет: на все заданные вопросы в базе есть ответ"))
+		}
+
+		for _, cluster := range clusters {
+			info := fmt.Sprintf("Задан раз: %d · последний раз %s", len(cluster.Questions), cluster.LastAsked)
+			if cluster.Negative > 0 {
+				info += fmt.Sprintf(" · не помогло: %d", cluster.Negative)
+			}
+
+			questionsBox := container.NewVBox()
+			for _, q := range cluster.Questions {
+				label := widget.NewLabel(q.Date + " · " + q.Question)
+				label.Wrapping = fyne.TextWrapWord
+				questionsBox.Add(label)
+			}
+			details := widget.NewAccordion(widget.NewAccordionItem("Все формулировки", questionsBox))
+
+			resolveBtn := widget.NewButtonWithIcon("Написать ответ", theme.DocumentCreateIcon(), func() {
+				// Последний ответ на вопросы группы — черновик, который оператор правит.
+				// У вопросов, где оператор не выбрал ни одну из найденных записей, ответа нет
+				draft := FAQEntry{Question: cluster.Title()}
+				for _, q := range cluster.Questions {
+					if q.Answer != "" {
+						draft.Answer = q.Answer
+						break
+					}
+				}
+				showDraftDialog(faq, w, "Ответ на группу вопросов", draft, func(entry FAQEntry) (FAQEntry, error) {
+					return resolveGap(db, faq, cluster, entry)
+				}, func(FAQEntry) {
+					refresh()
+				})
+			})
+			resolveBtn.Importance = widget.HighImportance
+
+			content := container.NewVBox(details, container.NewHBox(layout.NewSpacer(), resolveBtn))
+			list.Add(widget.NewCard(cluster.Title(), info, content))
+		}
+		list.Refresh()
+	}
+	refresh()
+
+	refreshBtn := widget.NewButtonWithIcon("Обновить", theme.ViewRefreshIcon(), refresh)
+
+	scroll := container.NewScroll(list)
+	scroll.SetMinSize(fyne.NewSize(800, 600))
+	return container.NewBorder(
+		container.NewHBox(
+			widget.NewLabelWithStyle("Вопросы без ответа в базе", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			summary,
+			layout.NewSpacer(),
+			refreshBtn,
+		),
+		nil, nil, nil,
+		scroll,
+	), refresh
+}
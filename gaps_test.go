@@ -0,0 +1,119 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClusterGaps(t *testing.T) {
+	type cluster struct {
+		ids       []int
+		lastAsked string
+		negative  int
+	}
+	tests := []struct {
+		name      string
+		questions []GapQuestion
+		want      []cluster
+	}{
+		{
+			name: "порог сходства включительно",
+			questions: []GapQuestion{
+				{HistoryID: 1, Question: "Принтер не печатает", Date: "2026-10-05"},
+				// 2 общих слова из 4 — ровно порог
+				{HistoryID: 2, Question: "Принтер не печатает документ снова", Date: "2026-10-04"},
+				// 2 общих слова из 5 — ниже порога
+				{HistoryID: 3, Question: "Принтер не печатает документ снова утром", Date: "2026-10-03"},
+			},
+			want: []cluster{
+				{ids: []int{1, 2}, lastAsked: "2026-10-05"},
+				{ids: []int{3}, lastAsked: "2026-10-03"},
+			},
+		},
+		{
+			name: "вопрос попадает в самую похожую группу",
+			questions: []GapQuestion{
+				{HistoryID: 1, Question: "Не работает VPN", Date: "2026-10-05"},
+				{HistoryID: 2, Question: "VPN не подключается дома", Date: "2026-10-04"},
+				// С первой группой 2 общих слова из 3, со второй — 2 из 4
+				{HistoryID: 3, Question: "Работает VPN, подключается?", Date: "2026-10-03", Negative: true},
+			},
+			want: []cluster{
+				{ids: []int{1, 3}, lastAsked: "2026-10-05", negative: 1},
+				{ids: []int{2}, lastAsked: "2026-10-04"},
+			},
+		},
+		{
+			name: "формы слова совпадают",
+			questions: []GapQuestion{
+				{HistoryID: 1, Question: "Не печатает принтер", Date: "2026-10-05"},
+				{HistoryID: 2, Question: "Принтера не печатают", Date: "2026-10-04", Negative: true},
+			},
+			want: []cluster{
+				{ids: []int{1, 2}, lastAsked: "2026-10-05", negative: 1},
+			},
+		},
+		{
+			name: "вопросы без значимых слов не объединяются",
+			questions: []GapQuestion{
+				{HistoryID: 1, Question: "да?", Date: "2026-10-05"},
+				{HistoryID: 2, Question: "да?", Date: "2026-10-04"},
+			},
+			want: []cluster{
+				{ids: []int{1}, lastAsked: "2026-10-05"},
+				{ids: []int{2}, lastAsked: "2026-10-04"},
+			},
+		},
+		{
+			name: "большие группы выше, при равенстве — недавние",
+			questions: []GapQuestion{
+				{HistoryID: 1, Question: "Нет звука", Date: "2026-10-09"},
+				{HistoryID: 2, Question: "Забыл пароль", Date: "2026-10-08"},
+				{HistoryID: 3, Question: "Принтер не печатает", Date: "2026-10-07"},
+				{HistoryID: 4, Question: "принтер не печатает", Date: "2026-10-06"},
+			},
+			want: []cluster{
+				{ids: []int{3, 4}, lastAsked: "2026-10-07"},
+				{ids: []int{1}, lastAsked: "2026-10-09"},
+				{ids: []int{2}, lastAsked: "2026-10-08"},
+			},
+		},
+		{
+			name: "нет вопросов",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []cluster
+			for _, c := range clusterGaps(tt.questions) {
+				got = append(got, cluster{ids: c.historyIDs(), lastAsked: c.LastAsked, negative: c.Negative})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("группы %+v, ожидались %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGapClusterTitle(t *testing.T) {
+	tests := []struct {
+		questions []string
+		want      string
+	}{
+		{[]string{"Пропал звук", "Нет звука", "нет звука "}, "Нет звука"},
+		// При равенстве — последняя заданная, она идет первой
+		{[]string{"Пропал звук", "Нет звука"}, "Пропал звук"},
+		{[]string{" Нет звука "}, "Нет звука"},
+	}
+
+	for _, tt := range tests {
+		var c GapCluster
+		for _, q := range tt.questions {
+			c.Questions = append(c.Questions, GapQuestion{Question: q})
+		}
+		if got := c.Title(); got != tt.want {
+			t.Errorf("Title(%q) = %q, ожидалось %q", tt.questions, got, tt.want)
+		}
+	}
+}
@@ -1,4 +1,4 @@
-module main
+module support
 
 go 1.23
 
@@ -6,16 +6,16 @@ toolchain go1.23.9
 
 require (
 	fyne.io/fyne/v2 v2.6.1
+	github.com/BurntSushi/toml v1.4.0
 	github.com/blevesearch/bleve/v2 v2.5.1
+	github.com/blevesearch/bleve_index_api v1.2.8
 	github.com/mattn/go-sqlite3 v1.14.28
 )
 
 require (
 	fyne.io/systray v1.11.0 // indirect
-	github.com/BurntSushi/toml v1.4.0 // indirect
 	github.com/RoaringBitmap/roaring/v2 v2.4.5 // indirect
 	github.com/bits-and-blooms/bitset v1.22.0 // indirect
-	github.com/blevesearch/bleve_index_api v1.2.8 // indirect
 	github.com/blevesearch/geo v0.2.3 // indirect
 	github.com/blevesearch/go-faiss v1.0.25 // indirect
 	github.com/blevesearch/go-porterstemmer v1.0.3 // indirect
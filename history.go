@@ -0,0 +1,496 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Откуда взят ответ, сохраненный в истории
+const (
+	HistorySourceExact = "exact" // точное совпадение вопроса с записью FAQ
+	HistorySourceFAQ   = "faq"   // запись найдена поиском
+	HistorySourceLLM   = "llm"   // ответ сгенерирован моделью
+)
+
+// historySources — источники ответов и их названия для оператора
+var historySources = []struct {
+	value string
+	title string
+}{
+	{HistorySourceExact, "Точное совпадение"},
+	{HistorySourceFAQ, "Найдено в FAQ"},
+	{HistorySourceLLM, "Ответ ИИ"},
+}
+
+// historySourceTitle возвращает название источника; для старых записей без источника — пустую строку
+func historySourceTitle(source string) string {
+	for _, s := range historySources {
+		if s.value == source {
+			return s.title
+		}
+	}
+	return ""
+}
+
+// historyPageSize — сколько записей истории показывается на одной странице
+const historyPageSize = 20
+
+// Добавляю структуру для истории
+type HistoryEntry struct {
+	ID       int
+	Question string
+	Answer   string
+	Date     string
+	Provenance
+	Sources       []Citation // записи FAQ, переданные модели как контекст
+	PromotedFAQID int        // запись FAQ, созданная из этого ответа; 0 — ответ не добавлялся в базу
+}
+
+// Citation — запись FAQ, переданная модели как контекст.
+// Вопрос хранится копией, чтобы ссылка читалась и после правки или удаления записи
+type Citation struct {
+	FAQID    int
+	Question string
+}
+
+func (c Citation) String() string {
+	return fmt.Sprintf("#%d %s", c.FAQID, c.Question)
+}
+
+// citationsFor превращает записи FAQ из контекста запроса в список источников
+func citationsFor(entries []FAQEntry) []Citation {
+	citations := make([]Citation, 0, len(entries))
+	for _, entry := range entries {
+		citations = append(citations, Citation{FAQID: entry.ID, Question: entry.Question})
+	}
+	return citations
+}
+
+// Добавляю функции для работы с историей.
+// saveToHistory сохраняет ответ вместе с его происхождением и источниками
+// и возвращает идентификатор новой записи истории
+func saveToHistory(db *sql.DB, entry HistoryEntry) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		INSERT INTO history (question, answer, source, faq_id, score, model, options, latency_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, entry.Question, entry.Answer, entry.Source, nullID(entry.FAQID), entry.Score,
+		entry.Model, entry.Options, entry.Latency.Milliseconds())
+	if err != nil {
+		return 0, err
+	}
+	historyID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := saveHistorySources(tx, int(historyID), entry.Sources); err != nil {
+		return 0, err
+	}
+	return int(historyID), tx.Commit()
+}
+
+// replaceHistoryAnswer записывает новый ответ в уже сохраненный вопрос истории.
+// Так вопрос, по которому оператор после найденных записей спросил модель, остается одной записью
+func replaceHistoryAnswer(db *sql.DB, historyID int, entry HistoryEntry) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE history SET answer = ?, source = ?, faq_id = ?, score = ?, model = ?, options = ?, latency_ms = ?
+		WHERE id = ?
+	`, entry.Answer, entry.Source, nullID(entry.FAQID), entry.Score,
+		entry.Model, entry.Options, entry.Latency.Milliseconds(), historyID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM history_sources WHERE history_id = ?", historyID); err != nil {
+		return err
+	}
+	if err := saveHistorySources(tx, historyID, entry.Sources); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// saveHistorySources сохраняет записи FAQ, переданные модели, вместе с записью истории
+func saveHistorySources(tx *sql.Tx, historyID int, sources []Citation) error {
+	for _, source := range sources {
+		_, err := tx.Exec("INSERT INTO history_sources (history_id, faq_id, question) VALUES (?, ?, ?)",
+			historyID, source.FAQID, source.Question)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chooseHistoryAnswer записывает в историю запись FAQ, которую оператор выбрал среди найденных
+func chooseHistoryAnswer(db *sql.DB, historyID int, hit SearchHit) error {
+	_, err := db.Exec("UPDATE history SET answer = ?, faq_id = ?, score = ? WHERE id = ?",
+		hit.Entry.Answer, hit.Entry.ID, hit.Score, historyID)
+	return err
+}
+
+// loadCitations загружает источники, сохраненные вместе с записью истории
+func loadCitations(db *sql.DB, historyID int) ([]Citation, error) {
+	rows, err := db.Query("SELECT faq_id, question FROM history_sources WHERE history_id = ? ORDER BY id", historyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var citations []Citation
+	for rows.Next() {
+		var citation Citation
+		if err := rows.Scan(&citation.FAQID, &citation.Question); err != nil {
+			return nil, err
+		}
+		citations = append(citations, citation)
+	}
+	return citations, rows.Err()
+}
+
+// HistoryQuery — условия отбора записей истории
+type HistoryQuery struct {
+	Text   string     // слова из вопроса или ответа
+	From   *time.Time // с этого дня включительно, по местному времени
+	To     *time.Time // по этот день включительно
+	Source string     // пусто — любой источник
+	Offset int
+	Limit  int
+}
+
+// searchHistory возвращает страницу истории, подходящую под условия, и общее число таких записей
+func searchHistory(db *sql.DB, q HistoryQuery) ([]HistoryEntry, int, error) {
+	where := []string{"deleted_at IS NULL"}
+	var args []any
+	if match := ftsQuery(q.Text); match != "" {
+		where = append(where, "id IN (SELECT docid FROM history_fts WHERE history_fts MATCH ?)")
+		args = append(args, match)
+	}
+	if q.From != nil {
+		where = append(where, "date(date, 'localtime') >= ?")
+		args = append(args, q.From.Format(time.DateOnly))
+	}
+	if q.To != nil {
+		where = append(where, "date(date, 'localtime') <= ?")
+		args = append(args, q.To.Format(time.DateOnly))
+	}
+	if q.Source != "" {
+		where = append(where, "source = ?")
+		args = append(args, q.Source)
+	}
+	cond := strings.Join(where, " AND ")
+
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM history WHERE "+cond, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := db.Query(`
+		SELECT id, question, answer, date, source, IFNULL(faq_id, 0), score, model, options, latency_ms,
+			IFNULL(promoted_faq_id, 0)
+		FROM history WHERE `+cond+`
+		ORDER BY date DESC, id DESC LIMIT ? OFFSET ?`, append(args, q.Limit, q.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var history []HistoryEntry
+	for rows.Next() {
+		var entry HistoryEntry
+		var latencyMS int64
+		err := rows.Scan(&entry.ID, &entry.Question, &entry.Answer, &entry.Date, &entry.Source,
+			&entry.FAQID, &entry.Score, &entry.Model, &entry.Options, &latencyMS, &entry.PromotedFAQID)
+		if err != nil {
+			return nil, 0, err
+		}
+		entry.Latency = time.Duration(latencyMS) * time.Millisecond
+		history = append(history, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	for i := range history {
+		history[i].Sources, err = loadCitations(db, history[i].ID)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return history, total, nil
+}
+
+// ftsQuery превращает введенный текст в запрос FTS: все слова должны встретиться,
+// каждое ищется как начало слова. Кавычки и операторы FTS отбрасываются
+func ftsQuery(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = word + "*"
+	}
+	return strings.Join(words, " ")
+}
+
+// createHistoryTab создает вкладку "История" с поиском, фильтрами и постраничным просмотром.
+// onRerun повторяет поиск по вопросу на вкладке "Поиск".
+// Возвращает также функцию, перечитывающую текущую страницу; ее можно вызывать из любой горутины
+func createHistoryTab(db *sql.DB, faq *FAQService, favorites *FavoritesStore, w fyne.Window, onRerun func(question string)) (fyne.CanvasObject, func()) {
+	const allSources = "Все источники"
+
+	searchEntry := widget.NewEntry()
+	searchEntry.SetPlaceHolder("Поиск по вопросам и ответам")
+	fromEntry := widget.NewDateEntry()
+	fromEntry.SetPlaceHolder("С даты")
+	toEntry := widget.NewDateEntry()
+	toEntry.SetPlaceHolder("По дату")
+	sourceOptions := []string{allSources}
+	for _, s := range historySources {
+		sourceOptions = append(sourceOptions, s.title)
+	}
+	sourceSelect := widget.NewSelect(sourceOptions, nil)
+	sourceSelect.SetSelected(allSources)
+
+	pageLabel := widget.NewLabel("")
+	prevBtn := widget.NewButtonWithIcon("Назад", theme.NavigateBackIcon(), nil)
+	nextBtn := widget.NewButtonWithIcon("Вперед", theme.NavigateNextIcon(), nil)
+
+	// Страница и смещение меняются только в потоке UI. Текущие условия нужны
+	// и фоновому reload, поэтому защищены мьютексом
+	var (
+		page      []HistoryEntry
+		offset    int
+		total     int
+		list      *widget.List
+		currentMu sync.Mutex
+		current   HistoryQuery
+	)
+
+	// query собирает условия из полей фильтра; вызывается в потоке UI
+	query := func() HistoryQuery {
+		q := HistoryQuery{
+			Text:  searchEntry.Text,
+			From:  fromEntry.Date,
+			To:    toEntry.Date,
+			Limit: historyPageSize,
+		}
+		for _, s := range historySources {
+			if s.title == sourceSelect.Selected {
+				q.Source = s.value
+			}
+		}
+		return q
+	}
+
+	show := func(entries []HistoryEntry, count int) {
+		page, total = entries, count
+		pages := max((total+historyPageSize-1)/historyPageSize, 1)
+		pageLabel.SetText(fmt.Sprintf("Стр. %d из %d · записей: %d", offset/historyPageSize+1, pages, total))
+		if offset > 0 {
+			prevBtn.Enable()
+		} else {
+			prevBtn.Disable()
+		}
+		if offset+historyPageSize < total {
+			nextBtn.Enable()
+		} else {
+			nextBtn.Disable()
+		}
+		list.Refresh()
+	}
+
+	load := func(q HistoryQuery) ([]HistoryEntry, int) {
+		entries, count, err := searchHistory(db, q)
+		if err != nil {
+			log.Printf("Ошибка загрузки истории: %v", err)
+		}
+		return entries, count
+	}
+
+	// apply применяет фильтры и открывает страницу с началом в newOffset
+	var apply func(newOffset int)
+	apply = func(newOffset int) {
+		offset = newOffset
+		q := query()
+		q.Offset = offset
+		currentMu.Lock()
+		current = q
+		currentMu.Unlock()
+
+		entries, count := load(q)
+		// После удаления последней записи на странице возвращаемся на предыдущую
+		if len(entries) == 0 && offset > 0 {
+			apply(max(offset-historyPageSize, 0))
+			return
+		}
+		show(entries, count)
+	}
+	prevBtn.OnTapped = func() { apply(max(offset-historyPageSize, 0)) }
+	nextBtn.OnTapped = func() { apply(offset + historyPageSize) }
+	searchEntry.OnSubmitted = func(string) { apply(0) }
+	fromEntry.OnChanged = func(*time.Time) { apply(0) }
+	toEntry.OnChanged = func(*time.Time) { apply(0) }
+	sourceSelect.OnChanged = func(string) { apply(0) }
+
+	// reload перечитывает текущую страницу с теми же фильтрами
+	reload := func() {
+		currentMu.Lock()
+		q := current
+		currentMu.Unlock()
+		entries, count := load(q)
+		fyne.Do(func() {
+			show(entries, count)
+		})
+	}
+
+	list = widget.NewList(
+		func() int { return len(page) },
+		func() fyne.CanvasObject {
+			questionLabel := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			questionLabel.Truncation = fyne.TextTruncateEllipsis
+			answerLabel := widget.NewLabel("")
+			answerLabel.Truncation = fyne.TextTruncateEllipsis
+			sourcesLabel := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Italic: true})
+			sourcesLabel.Truncation = fyne.TextTruncateEllipsis
+
+			rerunBtn := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), nil)
+			copyBtn := widget.NewButtonWithIcon("", theme.ContentCopyIcon(), nil)
+			favoriteBtn := widget.NewButtonWithIcon("", theme.FolderNewIcon(), nil)
+			promoteBtn := widget.NewButtonWithIcon("", theme.ContentAddIcon(), nil)
+			deleteBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+			return container.NewBorder(nil, nil, nil,
+				container.NewHBox(rerunBtn, copyBtn, favoriteBtn, promoteBtn, deleteBtn),
+				container.NewVBox(
+					questionLabel,
+					widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Italic: true}),
+					answerLabel,
+					sourcesLabel,
+				))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			entry := page[id]
+			row := item.(*fyne.Container)
+			box := row.Objects[0].(*fyne.Container)
+			buttons := row.Objects[1].(*fyne.Container)
+
+			box.Objects[0].(*widget.Label).SetText(entry.Question)
+			meta := entry.Date
+			if provenance := entry.describe(); provenance != "" {
+				meta += " · " + provenance
+			}
+			if entry.PromotedFAQID != 0 {
+				meta += fmt.Sprintf(" · добавлен в базу как #%d", entry.PromotedFAQID)
+			}
+			box.Objects[1].(*widget.Label).SetText(meta)
+			// Записи, найденные поиском, попадают в историю без ответа, пока оператор не выберет одну из них
+			answer := entry.Answer
+			if answer == "" {
+				answer = "Ответ не выбран"
+			}
+			box.Objects[2].(*widget.Label).SetText(answer)
+
+			sourcesLabel := box.Objects[3].(*widget.Label)
+			if len(entry.Sources) == 0 {
+				sourcesLabel.Hide()
+			} else {
+				sources := make([]string, 0, len(entry.Sources))
+				for _, source := range entry.Sources {
+					sources = append(sources, source.String())
+				}
+				sourcesLabel.SetText("Источники: " + strings.Join(sources, "; "))
+				sourcesLabel.Show()
+			}
+
+			buttons.Objects[0].(*widget.Button).OnTapped = func() {
+				onRerun(entry.Question)
+			}
+			copyBtn, favoriteBtn := buttons.Objects[1].(*widget.Button), buttons.Objects[2].(*widget.Button)
+			if entry.Answer == "" {
+				copyBtn.Disable()
+				favoriteBtn.Disable()
+			} else {
+				copyBtn.Enable()
+				favoriteBtn.Enable()
+			}
+			copyBtn.OnTapped = func() {
+				w.Clipboard().SetContent(entry.Answer)
+				dialog.ShowInformation("Успех", "Ответ скопирован в буфер обмена", w)
+			}
+			favoriteBtn.OnTapped = func() {
+				err := favorites.Add(FavoriteRef{HistoryID: entry.ID}, entry.Question, entry.Answer)
+				if errors.Is(err, ErrFavoriteExists) {
+					dialog.ShowInformation("Избранное", "Этот ответ уже в избранном", w)
+					return
+				}
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				dialog.ShowInformation("Успех", "Ответ добавлен в избранное", w)
+			}
+			// В базу добавляются только ответы модели: остальные и так взяты из нее
+			promoteBtn := buttons.Objects[3].(*widget.Button)
+			if entry.Source == HistorySourceLLM && entry.PromotedFAQID == 0 {
+				promoteBtn.Enable()
+			} else {
+				promoteBtn.Disable()
+			}
+			promoteBtn.OnTapped = func() {
+				showPromoteDialog(db, faq, w, entry.ID, FAQEntry{Question: entry.Question, Answer: entry.Answer}, func(FAQEntry) {
+					apply(offset)
+				})
+			}
+			buttons.Objects[4].(*widget.Button).OnTapped = func() {
+				if err := trashRow(db, TrashHistory, entry.ID); err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				apply(offset)
+			}
+		},
+	)
+	apply(0)
+
+	resetBtn := widget.NewButtonWithIcon("Сбросить", theme.ContentClearIcon(), func() {
+		searchEntry.SetText("")
+		fromEntry.SetDate(nil)
+		toEntry.SetDate(nil)
+		sourceSelect.SetSelected(allSources)
+		apply(0)
+	})
+	searchBtn := widget.NewButtonWithIcon("Найти", theme.SearchIcon(), func() {
+		apply(0)
+	})
+	searchBtn.Importance = widget.HighImportance
+
+	filters := container.NewVBox(
+		container.NewBorder(nil, nil, nil, container.NewHBox(searchBtn, resetBtn), searchEntry),
+		container.NewGridWithColumns(3, fromEntry, toEntry, sourceSelect),
+	)
+	paging := container.NewHBox(prevBtn, layout.NewSpacer(), pageLabel, layout.NewSpacer(), nextBtn)
+
+	return container.NewBorder(filters, paging, nil, nil, list), reload
+}
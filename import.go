@@ -0,0 +1,551 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Форматы файлов импорта и экспорта
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// formatFromPath определяет формат файла по расширению
+func formatFromPath(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".json":
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("не удалось определить формат файла %s: ожидается .csv или .json", path)
+	}
+}
+
+// ImportTable — содержимое файла импорта: названия столбцов и строки со значениями по столбцам
+type ImportTable struct {
+	Columns []string
+	Rows    []ImportRecord
+}
+
+// ImportRecord — строка файла импорта
+type ImportRecord struct {
+	Line   int // строка CSV или номер элемента массива JSON, с 1
+	Values map[string]string
+}
+
+// readImport читает CSV с заголовком или JSON-массив объектов
+func readImport(r io.Reader, format string) (ImportTable, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return ImportTable{}, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	var table ImportTable
+	switch format {
+	case FormatCSV:
+		table, err = readImportCSV(data)
+	case FormatJSON:
+		table, err = readImportJSON(data)
+	default:
+		return ImportTable{}, fmt.Errorf("неизвестный формат %q", format)
+	}
+	if err != nil {
+		return ImportTable{}, fmt.Errorf("ошибка чтения %s: %w", strings.ToUpper(format), err)
+	}
+	return table, nil
+}
+
+// readImportCSV читает CSV; разделитель (запятая, точка с запятой или табуляция)
+// определяется по строке заголовка, потому что Excel сохраняет CSV по-разному
+func readImportCSV(data []byte) (ImportTable, error) {
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+	delimiter := ','
+	best := bytes.Count(header, []byte(","))
+	for _, d := range []rune{';', '\t'} {
+		if n := bytes.Count(header, []byte(string(d))); n > best {
+			delimiter, best = d, n
+		}
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	columns, err := reader.Read()
+	if err == io.EOF {
+		return ImportTable{}, errors.New("файл пуст")
+	}
+	if err != nil {
+		return ImportTable{}, err
+	}
+	for i := range columns {
+		columns[i] = strings.TrimSpace(columns[i])
+	}
+
+	table := ImportTable{Columns: columns}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return ImportTable{}, err
+		}
+		line, _ := reader.FieldPos(0)
+		values := make(map[string]string, len(columns))
+		for i, column := range columns {
+			if i < len(record) {
+				values[column] = record[i]
+			}
+		}
+		table.Rows = append(table.Rows, ImportRecord{Line: line, Values: values})
+	}
+	return table, nil
+}
+
+// readImportJSON читает массив объектов. Столбцы — все ключи объектов по алфавиту;
+// числа записываются строкой, массивы (например, теги) — через запятую
+func readImportJSON(data []byte) (ImportTable, error) {
+	var objects []map[string]any
+	if err := json.Unmarshal(data, &objects); err != nil {
+		return ImportTable{}, err
+	}
+
+	seen := make(map[string]bool)
+	var table ImportTable
+	for i, object := range objects {
+		values := make(map[string]string, len(object))
+		for key, value := range object {
+			if !seen[key] {
+				seen[key] = true
+				table.Columns = append(table.Columns, key)
+			}
+			values[key] = jsonValueString(value)
+		}
+		table.Rows = append(table.Rows, ImportRecord{Line: i + 1, Values: values})
+	}
+	sort.Strings(table.Columns)
+	return table, nil
+}
+
+func jsonValueString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, jsonValueString(item))
+		}
+		return strings.Join(parts, ", ")
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// ImportMapping — столбцы файла, из которых берутся поля записи FAQ; пустое имя — поле не заполняется
+type ImportMapping struct {
+	Question string
+	Answer   string
+	Category string
+	Tags     string
+}
+
+// importColumnNames — названия столбцов, которые сопоставляются с полями без участия оператора
+var importColumnNames = map[string][]string{
+	"question": {"question", "вопрос"},
+	"answer":   {"answer", "ответ"},
+	"category": {"category", "категория"},
+	"tags":     {"tags", "теги", "tag", "тег"},
+}
+
+// guessMapping сопоставляет столбцы с полями по названиям без учета регистра
+func guessMapping(columns []string) ImportMapping {
+	find := func(field string) string {
+		for _, column := range columns {
+			for _, name := range importColumnNames[field] {
+				if strings.EqualFold(strings.TrimSpace(column), name) {
+					return column
+				}
+			}
+		}
+		return ""
+	}
+	return ImportMapping{
+		Question: find("question"),
+		Answer:   find("answer"),
+		Category: find("category"),
+		Tags:     find("tags"),
+	}
+}
+
+// Что произойдет со строкой при импорте
+const (
+	ImportNew       = "new"       // новая запись
+	ImportDuplicate = "duplicate" // в базе уже есть запись с таким вопросом
+	ImportRepeated  = "repeated"  // вопрос уже встречался выше в файле
+	ImportInvalid   = "invalid"   // не заполнен вопрос или ответ
+)
+
+// ImportRow — строка файла, подготовленная к импорту
+type ImportRow struct {
+	Line     int
+	Entry    FAQEntry
+	Status   string
+	Existing int // запись FAQ с тем же вопросом, для ImportDuplicate
+}
+
+// describe возвращает состояние строки для предпросмотра
+func (r ImportRow) describe() string {
+	switch r.Status {
+	case ImportNew:
+		return "новая"
+	case ImportDuplicate:
+		return fmt.Sprintf("уже есть: #%d", r.Existing)
+	case ImportRepeated:
+		return "повтор в файле"
+	default:
+		return "нет вопроса или ответа"
+	}
+}
+
+// ImportSummary — число строк в каждом состоянии
+type ImportSummary map[string]int
+
+func (s ImportSummary) String() string {
+	return fmt.Sprintf("Новых: %d · Уже в базе: %d · Повторов в файле: %d · Без вопроса или ответа: %d",
+		s[ImportNew], s[ImportDuplicate], s[ImportRepeated], s[ImportInvalid])
+}
+
+// summarizeImport считает строки по состояниям
+func summarizeImport(rows []ImportRow) ImportSummary {
+	summary := make(ImportSummary)
+	for _, row := range rows {
+		summary[row.Status]++
+	}
+	return summary
+}
+
+// questionKey — вопрос в виде для сравнения, как в FAQService.FindExact
+func questionKey(question string) string {
+	return strings.ToLower(strings.TrimSpace(question))
+}
+
+// prepareImport переводит строки файла в записи FAQ и находит дубликаты:
+// записи с тем же вопросом в базе и повторы внутри файла.
+// Столбец id, если он есть (например, в файле экспорта), не используется: записи сопоставляются по вопросу
+func prepareImport(table ImportTable, mapping ImportMapping, faq *FAQService) []ImportRow {
+	existing := make(map[string]int)
+	for _, entry := range faq.Entries() {
+		key := questionKey(entry.Question)
+		if _, ok := existing[key]; !ok {
+			existing[key] = entry.ID
+		}
+	}
+
+	inFile := make(map[string]bool)
+	rows := make([]ImportRow, 0, len(table.Rows))
+	for _, record := range table.Rows {
+		value := func(column string) string {
+			if column == "" {
+				return ""
+			}
+			return strings.TrimSpace(record.Values[column])
+		}
+		row := ImportRow{
+			Line: record.Line,
+			Entry: FAQEntry{
+				Question: value(mapping.Question),
+				Answer:   value(mapping.Answer),
+				Category: value(mapping.Category),
+				Tags:     parseTags(value(mapping.Tags)),
+			},
+		}
+
+		key := questionKey(row.Entry.Question)
+		switch {
+		case row.Entry.Question == "" || row.Entry.Answer == "":
+			row.Status = ImportInvalid
+		case inFile[key]:
+			row.Status = ImportRepeated
+		case existing[key] != 0:
+			row.Status = ImportDuplicate
+			row.Existing = existing[key]
+		default:
+			row.Status = ImportNew
+		}
+		inFile[key] = true
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// ImportResult — итог импорта
+type ImportResult struct {
+	Created int
+	Updated int
+	Skipped int
+}
+
+func (r ImportResult) String() string {
+	return fmt.Sprintf("Добавлено: %d · Обновлено: %d · Пропущено: %d", r.Created, r.Updated, r.Skipped)
+}
+
+// Import добавляет новые записи одной транзакцией и одним пакетом индексации.
+// Записи, вопрос которых уже есть в базе, обновляются, только если updateDuplicates;
+// остальные строки пропускаются. При ошибке в базе и индексе ничего не меняется
+func (s *FAQService) Import(rows []ImportRow, updateDuplicates bool) (ImportResult, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return ImportResult{}, err
+	}
+	defer tx.Rollback()
+
+	var result ImportResult
+	var saved, replaced []FAQEntry
+	batch := s.index.NewBatch()
+	for _, row := range rows {
+		entry := normalizeEntry(row.Entry)
+		var old FAQEntry
+		switch {
+		case row.Status == ImportNew:
+		case row.Status == ImportDuplicate && updateDuplicates:
+			var ok bool
+			if old, ok = s.Get(row.Existing); !ok {
+				result.Skipped++
+				continue
+			}
+			entry.ID = old.ID
+		default:
+			result.Skipped++
+			continue
+		}
+
+		categoryID, err := resolveCategory(tx, entry.Category)
+		if err != nil {
+			return ImportResult{}, fmt.Errorf("строка %d: %w", row.Line, err)
+		}
+		if entry.ID == 0 {
+			res, err := tx.Exec("INSERT INTO faq (question, answer, category_id) VALUES (?, ?, ?)", entry.Question, entry.Answer, categoryID)
+			if err != nil {
+				return ImportResult{}, fmt.Errorf("строка %d: %w", row.Line, err)
+			}
+			id, err := res.LastInsertId()
+			if err != nil {
+				return ImportResult{}, err
+			}
+			entry.ID = int(id)
+			result.Created++
+		} else {
+			_, err := tx.Exec("UPDATE faq SET question = ?, answer = ?, category_id = ? WHERE id = ?", entry.Question, entry.Answer, categoryID, entry.ID)
+			if err != nil {
+				return ImportResult{}, fmt.Errorf("строка %d: %w", row.Line, err)
+			}
+			replaced = append(replaced, old)
+			result.Updated++
+		}
+		if err := saveTags(tx, entry.ID, entry.Tags); err != nil {
+			return ImportResult{}, fmt.Errorf("строка %d: %w", row.Line, err)
+		}
+		if err := saveRevision(tx, old, entry, s.editor); err != nil {
+			return ImportResult{}, fmt.Errorf("строка %d: %w", row.Line, err)
+		}
+		if err := batch.Index(docID(entry.ID), entry.document()); err != nil {
+			return ImportResult{}, fmt.Errorf("строка %d: ошибка индексации: %w", row.Line, err)
+		}
+		saved = append(saved, entry)
+	}
+
+	if err := s.index.Batch(batch); err != nil {
+		return ImportResult{}, fmt.Errorf("ошибка индексации: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		// Возвращаем индекс к состоянию базы: новые документы убираем, обновленные откатываем
+		undo := s.index.NewBatch()
+		for _, entry := range saved {
+			undo.Delete(docID(entry.ID))
+		}
+		for _, old := range replaced {
+			undo.Index(docID(old.ID), old.document())
+		}
+		s.index.Batch(undo)
+		return ImportResult{}, err
+	}
+
+	s.store(saved...)
+	return result, nil
+}
+
+// showImportDialog предлагает выбрать файл CSV или JSON и открывает мастер импорта
+func showImportDialog(faq *FAQService, w fyne.Window) {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		format, err := formatFromPath(reader.URI().Name())
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		table, err := readImport(reader, format)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		if len(table.Rows) == 0 {
+			dialog.ShowInformation("Импорт", "В файле нет записей", w)
+			return
+		}
+		showImportWizard(faq, w, reader.URI().Name(), table)
+	}, w)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".csv", ".json"}))
+	open.Show()
+}
+
+// showImportWizard показывает сопоставление столбцов с полями записи и предпросмотр импорта.
+// Предпросмотр пересчитывается при каждом изменении сопоставления
+func showImportWizard(faq *FAQService, w fyne.Window, name string, table ImportTable) {
+	const noColumn = "—"
+	options := append([]string{noColumn}, table.Columns...)
+	mapping := guessMapping(table.Columns)
+
+	newColumnSelect := func(column string) *widget.Select {
+		sel := widget.NewSelect(options, nil)
+		sel.Selected = noColumn
+		if column != "" {
+			sel.Selected = column
+		}
+		return sel
+	}
+	questionSelect := newColumnSelect(mapping.Question)
+	answerSelect := newColumnSelect(mapping.Answer)
+	categorySelect := newColumnSelect(mapping.Category)
+	tagsSelect := newColumnSelect(mapping.Tags)
+	updateCheck := widget.NewCheck("Обновлять записи, вопрос которых уже есть в базе", nil)
+
+	currentMapping := func() ImportMapping {
+		column := func(sel *widget.Select) string {
+			if sel.Selected == noColumn {
+				return ""
+			}
+			return sel.Selected
+		}
+		return ImportMapping{
+			Question: column(questionSelect),
+			Answer:   column(answerSelect),
+			Category: column(categorySelect),
+			Tags:     column(tagsSelect),
+		}
+	}
+
+	var rows []ImportRow
+	summaryLabel := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	preview := widget.NewList(
+		func() int { return len(rows) },
+		func() fyne.CanvasObject {
+			status := widget.NewLabel("")
+			question := widget.NewLabel("")
+			question.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, status, nil, question)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			row := rows[id]
+			box := item.(*fyne.Container)
+			question := box.Objects[0].(*widget.Label)
+			status := box.Objects[1].(*widget.Label)
+			status.SetText(fmt.Sprintf("%d · %s", row.Line, row.describe()))
+			status.Importance = widget.MediumImportance
+			if row.Status != ImportNew {
+				status.Importance = widget.WarningImportance
+			}
+			status.Refresh()
+			text := row.Entry.Question
+			if row.Entry.Category != "" {
+				text += " · " + row.Entry.Category
+			}
+			question.SetText(text)
+		},
+	)
+
+	refresh := func() {
+		rows = prepareImport(table, currentMapping(), faq)
+		summaryLabel.SetText(summarizeImport(rows).String())
+		preview.Refresh()
+	}
+	for _, sel := range []*widget.Select{questionSelect, answerSelect, categorySelect, tagsSelect} {
+		sel.OnChanged = func(string) { refresh() }
+	}
+	refresh()
+
+	var wizard dialog.Dialog
+	importBtn := widget.NewButtonWithIcon("Импортировать", theme.DownloadIcon(), func() {
+		m := currentMapping()
+		if m.Question == "" || m.Answer == "" {
+			dialog.ShowInformation("Импорт", "Выберите столбцы с вопросом и ответом", w)
+			return
+		}
+		result, err := faq.Import(rows, updateCheck.Checked)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		wizard.Hide()
+		dialog.ShowInformation("Импорт завершен", result.String(), w)
+	})
+	importBtn.Importance = widget.HighImportance
+
+	form := widget.NewForm(
+		widget.NewFormItem("Вопрос", questionSelect),
+		widget.NewFormItem("Ответ", answerSelect),
+		widget.NewFormItem("Категория", categorySelect),
+		widget.NewFormItem("Теги", tagsSelect),
+	)
+	content := container.NewBorder(
+		container.NewVBox(
+			widget.NewLabelWithStyle("Столбцы файла", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			form,
+			updateCheck,
+			summaryLabel,
+		),
+		container.NewHBox(layout.NewSpacer(), importBtn),
+		nil, nil,
+		preview,
+	)
+
+	wizard = dialog.NewCustom("Импорт: "+name, "Отмена", content, w)
+	wizard.Resize(fyne.NewSize(900, 700))
+	wizard.Show()
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+// newTestFAQ создает FAQService поверх базы SQLite в памяти и индекса Bleve в памяти
+func newTestFAQ(t *testing.T, entries ...FAQEntry) *FAQService {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// У каждого соединения своя база в памяти, поэтому соединение одно
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	if _, err := runMigrations(db); err != nil {
+		t.Fatal(err)
+	}
+
+	indexMapping, err := buildIndexMapping()
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := bleve.NewMemOnly(indexMapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { index.Close() })
+
+	faq, err := NewFAQService(db, index, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if _, err := faq.Create(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return faq
+}
+
+func TestReadImport(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		data    string
+		columns []string
+		rows    []ImportRecord
+	}{
+		{
+			name:    "запятая",
+			format:  FormatCSV,
+			data:    "question,answer\nНет сети,Перезагрузите роутер\n",
+			columns: []string{"question", "answer"},
+			rows: []ImportRecord{
+				{Line: 2, Values: map[string]string{"question": "Нет сети", "answer": "Перезагрузите роутер"}},
+			},
+		},
+		{
+			name:    "точка с запятой и запятые в данных",
+			format:  FormatCSV,
+			data:    "Вопрос;Ответ\nНет сети;Проверьте кабель, затем роутер\n",
+			columns: []string{"Вопрос", "Ответ"},
+			rows: []ImportRecord{
+				{Line: 2, Values: map[string]string{"Вопрос": "Нет сети", "Ответ": "Проверьте кабель, затем роутер"}},
+			},
+		},
+		{
+			name:    "табуляция",
+			format:  FormatCSV,
+			data:    "question\tanswer\ttags\nНет сети\tПерезагрузите роутер\tсеть, роутер\n",
+			columns: []string{"question", "answer", "tags"},
+			rows: []ImportRecord{
+				{Line: 2, Values: map[string]string{"question": "Нет сети", "answer": "Перезагрузите роутер", "tags": "сеть, роутер"}},
+			},
+		},
+		{
+			name:    "BOM и пробелы в заголовке",
+			format:  FormatCSV,
+			data:    "\xef\xbb\xbf question ; answer \r\nНет сети;Перезагрузите роутер\r\n",
+			columns: []string{"question", "answer"},
+			rows: []ImportRecord{
+				{Line: 2, Values: map[string]string{"question": "Нет сети", "answer": "Перезагрузите роутер"}},
+			},
+		},
+		{
+			name:    "короткая строка",
+			format:  FormatCSV,
+			data:    "question,answer,category\nНет сети,Перезагрузите роутер\n",
+			columns: []string{"question", "answer", "category"},
+			rows: []ImportRecord{
+				{Line: 2, Values: map[string]string{"question": "Нет сети", "answer": "Перезагрузите роутер"}},
+			},
+		},
+		{
+			name:    "многострочный ответ",
+			format:  FormatCSV,
+			data:    "question,answer\n\"Нет сети\",\"Шаг 1\nШаг 2\"\nНет звука,Проверьте драйвер\n",
+			columns: []string{"question", "answer"},
+			rows: []ImportRecord{
+				{Line: 2, Values: map[string]string{"question": "Нет сети", "answer": "Шаг 1\nШаг 2"}},
+				{Line: 4, Values: map[string]string{"question": "Нет звука", "answer": "Проверьте драйвер"}},
+			},
+		},
+		{
+			name:    "JSON с BOM",
+			format:  FormatJSON,
+			data:    "\xef\xbb\xbf[{\"question\": \"Нет сети\", \"answer\": \"Перезагрузите роутер\", \"tags\": [\"сеть\", \"роутер\"], \"id\": 7}]",
+			columns: []string{"answer", "id", "question", "tags"},
+			rows: []ImportRecord{
+				{Line: 1, Values: map[string]string{"question": "Нет сети", "answer": "Перезагрузите роутер", "tags": "сеть, роутер", "id": "7"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := readImport(strings.NewReader(tt.data), tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(table.Columns, tt.columns) {
+				t.Errorf("столбцы %q, ожидались %q", table.Columns, tt.columns)
+			}
+			if !reflect.DeepEqual(table.Rows, tt.rows) {
+				t.Errorf("строки %v, ожидались %v", table.Rows, tt.rows)
+			}
+		})
+	}
+}
+
+func TestReadImportEmpty(t *testing.T) {
+	for _, format := range []string{FormatCSV, FormatJSON} {
+		if _, err := readImport(strings.NewReader("\xef\xbb\xbf"), format); err == nil {
+			t.Errorf("%s: пустой файл прочитан без ошибки", format)
+		}
+	}
+}
+
+func TestPrepareImport(t *testing.T) {
+	faq := newTestFAQ(t,
+		FAQEntry{Question: "Как настроить VPN?", Answer: "Откройте клиент VPN"},
+		FAQEntry{Question: "Не печатает принтер", Answer: "Проверьте очередь печати"},
+	)
+	vpn, _ := faq.FindExact("Как настроить VPN?")
+	printer, _ := faq.FindExact("Не печатает принтер")
+
+	table := ImportTable{Columns: []string{"question", "answer"}}
+	for i, values := range [][2]string{
+		{"Нет сети", "Перезагрузите роутер"},
+		{"  как настроить vpn?  ", "Новый ответ"},
+		{"НЕТ СЕТИ", "Повтор"},
+		{"Не печатает принтер", "Новый ответ"},
+		{"Не печатает принтер", "Еще раз"},
+		{"Нет звука", ""},
+		{"", "Ответ без вопроса"},
+		{"Нет звука", "Проверьте драйвер"},
+	} {
+		table.Rows = append(table.Rows, ImportRecord{
+			Line:   i + 2,
+			Values: map[string]string{"question": values[0], "answer": values[1]},
+		})
+	}
+
+	tests := []struct {
+		line     int
+		status   string
+		existing int
+	}{
+		{2, ImportNew, 0},
+		{3, ImportDuplicate, vpn.ID},
+		{4, ImportRepeated, 0},
+		{5, ImportDuplicate, printer.ID},
+		{6, ImportRepeated, 0},
+		{7, ImportInvalid, 0},
+		{8, ImportInvalid, 0},
+		// Строка без ответа не импортируется, но вопрос считается встреченным
+		{9, ImportRepeated, 0},
+	}
+
+	rows := prepareImport(table, guessMapping(table.Columns), faq)
+	if len(rows) != len(tests) {
+		t.Fatalf("подготовлено строк: %d, ожидалось %d", len(rows), len(tests))
+	}
+	for i, tt := range tests {
+		row := rows[i]
+		if row.Line != tt.line || row.Status != tt.status || row.Existing != tt.existing {
+			t.Errorf("строка %d: %s, запись %d; ожидалось %s, запись %d", row.Line, row.Status, row.Existing, tt.status, tt.existing)
+		}
+	}
+
+	summary := summarizeImport(rows)
+	want := ImportSummary{ImportNew: 1, ImportDuplicate: 2, ImportRepeated: 3, ImportInvalid: 2}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("итог %v, ожидался %v", summary, want)
+	}
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		name             string
+		updateDuplicates bool
+		result           ImportResult
+		vpnAnswer        string
+	}{
+		{"без обновления", false, ImportResult{Created: 1, Skipped: 2}, "Откройте клиент VPN"},
+		{"с обновлением", true, ImportResult{Created: 1, Updated: 1, Skipped: 1}, "Новый ответ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faq := newTestFAQ(t, FAQEntry{Question: "Как настроить VPN?", Answer: "Откройте клиент VPN"})
+			table := ImportTable{
+				Columns: []string{"question", "answer", "category", "tags"},
+				Rows: []ImportRecord{
+					{Line: 2, Values: map[string]string{"question": "Нет сети", "answer": "Перезагрузите роутер", "category": "Сеть / Дом", "tags": "Сеть, роутер"}},
+					{Line: 3, Values: map[string]string{"question": "Как настроить VPN?", "answer": "Новый ответ"}},
+					{Line: 4, Values: map[string]string{"question": "нет сети", "answer": "Повтор"}},
+				},
+			}
+
+			result, err := faq.Import(prepareImport(table, guessMapping(table.Columns), faq), tt.updateDuplicates)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result != tt.result {
+				t.Errorf("итог %+v, ожидался %+v", result, tt.result)
+			}
+
+			network, ok := faq.FindExact("Нет сети")
+			if !ok {
+				t.Fatal("новая запись не попала в кэш")
+			}
+			if network.Category != "Сеть/Дом" || !reflect.DeepEqual(network.Tags, []string{"роутер", "сеть"}) {
+				t.Errorf("категория %q и теги %q не приведены к каноническому виду", network.Category, network.Tags)
+			}
+			if vpn, _ := faq.FindExact("Как настроить VPN?"); vpn.Answer != tt.vpnAnswer {
+				t.Errorf("ответ %q, ожидался %q", vpn.Answer, tt.vpnAnswer)
+			}
+
+			stored, err := loadFAQEntries(faq.db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			count, err := faq.index.DocCount()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stored) != 2 || count != 2 {
+				t.Errorf("в базе %d записей, в индексе %d; ожидалось по 2", len(stored), count)
+			}
+		})
+	}
+}
+
+func TestImportRollback(t *testing.T) {
+	faq := newTestFAQ(t, FAQEntry{Question: "Как настроить VPN?", Answer: "Откройте клиент VPN"})
+	_, err := faq.db.Exec(`CREATE TRIGGER fail_import BEFORE INSERT ON faq WHEN NEW.question = 'Сбой'
+		BEGIN SELECT RAISE(ABORT, 'сбой вставки'); END`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := ImportTable{
+		Columns: []string{"question", "answer", "category"},
+		Rows: []ImportRecord{
+			{Line: 2, Values: map[string]string{"question": "Нет сети", "answer": "Перезагрузите роутер", "category": "Сеть"}},
+			{Line: 3, Values: map[string]string{"question": "Как настроить VPN?", "answer": "Новый ответ"}},
+			{Line: 4, Values: map[string]string{"question": "Сбой", "answer": "Эта строка не вставится"}},
+		},
+	}
+	_, err = faq.Import(prepareImport(table, guessMapping(table.Columns), faq), true)
+	if err == nil || !strings.Contains(err.Error(), "строка 4") {
+		t.Fatalf("ошибка %v, ожидалась ошибка строки 4", err)
+	}
+
+	stored, err := loadFAQEntries(faq.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 || stored[0].Answer != "Откройте клиент VPN" {
+		t.Errorf("база изменилась после ошибки: %+v", stored)
+	}
+	var categories int
+	if err := faq.db.QueryRow("SELECT COUNT(*) FROM categories").Scan(&categories); err != nil {
+		t.Fatal(err)
+	}
+	if categories != 0 {
+		t.Errorf("категорий после отката: %d", categories)
+	}
+	if entries := faq.Entries(); len(entries) != 1 || entries[0].Answer != "Откройте клиент VPN" {
+		t.Errorf("кэш изменился после ошибки: %+v", entries)
+	}
+	if count, _ := faq.index.DocCount(); count != 1 {
+		t.Errorf("документов в индексе: %d, ожидался 1", count)
+	}
+}
@@ -1,16 +1,19 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"database/sql"
-	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"html"
 	"image/color"
-	"io"
 	"log"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -21,7 +24,6 @@ import (
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
-	"github.com/blevesearch/bleve/v2"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -32,31 +34,42 @@ type FAQEntry struct {
 	ID       int
 	Question string
 	Answer   string
+	Category string   // путь категории, например "Сеть/VPN"; пустой — без категории
+	Tags     []string // в нижнем регистре, по алфавиту
 }
 
 // ResultCard представляет карточку с результатом поиска
 type ResultCard struct {
 	widget.BaseWidget
-	question string
-	answer   string
-	onCopy   func(string)
-	onSave   func(string, string)
-	onDelete func(string, string)
-}
-
-// OllamaRequest представляет запрос к Ollama API
-type OllamaRequest struct {
-	Model   string         `json:"model"`
-	Prompt  string         `json:"prompt"`
-	Stream  bool           `json:"stream"`
-	Context string         `json:"context,omitempty"`
-	Options map[string]any `json:"options,omitempty"`
-}
-
-// OllamaResponse представляет ответ от Ollama API
-type OllamaResponse struct {
-	Response string `json:"response"`
-	Done     bool   `json:"done"`
+	question    string
+	answer      string
+	answerLabel *widget.Label
+	sources     []Citation
+	onSource    func(Citation)
+	matchInfo   string
+	fragments   []string
+	onCopy      func(string)
+	onSave      func(string, string)
+	onDelete    func(string, string)
+	// ref — запись FAQ или истории, которую карточка добавляет в избранное;
+	// пока ее нет (ответ модели еще генерируется), кнопки избранного выключены
+	ref             FavoriteRef
+	saveButton      *widget.Button
+	deleteButton    *widget.Button
+	provenance      string
+	provenanceLabel *widget.Label
+	// historyID — запись истории с вопросом, к которой привязывается оценка ответа
+	historyID  int
+	onFeedback func(helpful bool)
+	upButton   *widget.Button
+	downButton *widget.Button
+	onPromote  func()
+	// promoted — ответ уже добавлен в базу; кнопка "Добавить в базу" выключается
+	promoted      bool
+	promoteButton *widget.Button
+	// onUse вызывается, когда оператор воспользовался ответом: скопировал,
+	// добавил в избранное или оценил как полезный
+	onUse func()
 }
 
 // NITITheme представляет кастомную тему в стиле НИТИ
@@ -121,12 +134,140 @@ func newResultCard(question, answer string, onCopy func(string), onSave func(str
 	return card
 }
 
+// setSources задает записи FAQ, на которые опирался ответ модели
+func (c *ResultCard) setSources(sources []Citation, onSource func(Citation)) {
+	c.sources = sources
+	c.onSource = onSource
+}
+
+// setMatch задает оценку релевантности и выделенные поиском фрагменты
+func (c *ResultCard) setMatch(info string, fragments []string) {
+	c.matchInfo = info
+	c.fragments = fragments
+}
+
+// setProvenance показывает, откуда взят ответ и сколько он занял.
+// После отрисовки карточки вызывается из потока UI
+func (c *ResultCard) setProvenance(info string) {
+	c.provenance = info
+	if c.provenanceLabel != nil {
+		c.provenanceLabel.SetText(info)
+		c.provenanceLabel.Show()
+	}
+}
+
+// setRef задает запись, которую карточка добавляет в избранное, и включает кнопки избранного.
+// После отрисовки карточки вызывается из потока UI
+func (c *ResultCard) setRef(ref FavoriteRef) {
+	c.ref = ref
+	c.refreshFavoriteButtons()
+}
+
+func (c *ResultCard) refreshFavoriteButtons() {
+	for _, button := range []*widget.Button{c.saveButton, c.deleteButton} {
+		if button == nil {
+			continue
+		}
+		if c.ref == (FavoriteRef{}) {
+			button.Disable()
+		} else {
+			button.Enable()
+		}
+	}
+}
+
+// setFeedback включает кнопки оценки ответа
+func (c *ResultCard) setFeedback(onFeedback func(helpful bool)) {
+	c.onFeedback = onFeedback
+}
+
+// setPromote включает кнопку "Добавить в базу" для ответа модели
+func (c *ResultCard) setPromote(onPromote func()) {
+	c.onPromote = onPromote
+}
+
+// markPromoted выключает кнопку "Добавить в базу", чтобы ответ не попал в базу дважды;
+// вызывается из потока UI
+func (c *ResultCard) markPromoted() {
+	c.promoted = true
+	if c.promoteButton != nil {
+		c.promoteButton.Disable()
+	}
+}
+
+// setUse задает действие, когда оператор воспользовался ответом карточки
+func (c *ResultCard) setUse(onUse func()) {
+	c.onUse = onUse
+}
+
+// used сообщает, что оператор воспользовался ответом карточки
+func (c *ResultCard) used() {
+	if c.onUse != nil {
+		c.onUse()
+	}
+}
+
+// markVote выделяет кнопку выставленной оценки; вызывается из потока UI
+func (c *ResultCard) markVote(helpful bool) {
+	if c.upButton == nil || c.downButton == nil {
+		return
+	}
+	c.upButton.Importance = widget.LowImportance
+	c.downButton.Importance = widget.LowImportance
+	if helpful {
+		c.upButton.Importance = widget.SuccessImportance
+	} else {
+		c.downButton.Importance = widget.DangerImportance
+	}
+	c.upButton.Refresh()
+	c.downButton.Refresh()
+}
+
+// highlightSegments превращает фрагмент с разметкой <mark> в текст с выделенными совпадениями
+func highlightSegments(fragment string) []widget.RichTextSegment {
+	var segments []widget.RichTextSegment
+	addText := func(text string, bold bool) {
+		if text == "" {
+			return
+		}
+		segments = append(segments, &widget.TextSegment{
+			Text:  html.UnescapeString(text),
+			Style: widget.RichTextStyle{Inline: true, TextStyle: fyne.TextStyle{Bold: bold}},
+		})
+	}
+
+	for {
+		start := strings.Index(fragment, "<mark>")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(fragment[start:], "</mark>")
+		if end < 0 {
+			break
+		}
+		addText(fragment[:start], false)
+		addText(fragment[start+len("<mark>"):start+end], true)
+		fragment = fragment[start+end+len("</mark>"):]
+	}
+	addText(fragment, false)
+	return segments
+}
+
+// appendAnswer дописывает фрагмент ответа в карточку; вызывается из потока UI
+func (c *ResultCard) appendAnswer(token string) {
+	c.answer += token
+	if c.answerLabel != nil {
+		c.answerLabel.SetText(c.answer)
+	}
+}
+
 func (c *ResultCard) CreateRenderer() fyne.WidgetRenderer {
 	questionLabel := widget.NewLabelWithStyle(c.question, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
 
 	answerLabel := widget.NewLabelWithStyle(c.answer, fyne.TextAlignLeading, fyne.TextStyle{})
 	answerLabel.Wrapping = fyne.TextWrapWord
 	answerLabel.Resize(fyne.NewSize(700, 0))
+	c.answerLabel = answerLabel
 
 	copyBtn := widget.NewButtonWithIcon("Копировать", theme.ContentCopyIcon(), func() {
 		if c.onCopy != nil {
@@ -148,85 +289,136 @@ func (c *ResultCard) CreateRenderer() fyne.WidgetRenderer {
 		}
 	})
 	deleteBtn.Importance = widget.HighImportance
+	c.saveButton, c.deleteButton = saveBtn, deleteBtn
+	c.refreshFavoriteButtons()
 
 	buttons := container.NewHBox(
 		copyBtn,
 		saveBtn,
 		deleteBtn,
 	)
+	if c.onPromote != nil {
+		promoteBtn := widget.NewButtonWithIcon("Добавить в базу", theme.ContentAddIcon(), c.onPromote)
+		promoteBtn.Importance = widget.HighImportance
+		if c.promoted {
+			promoteBtn.Disable()
+		}
+		c.promoteButton = promoteBtn
+		buttons.Objects = append([]fyne.CanvasObject{promoteBtn}, buttons.Objects...)
+	}
 
-	content := container.NewVBox(
-		questionLabel,
-		answerLabel,
-		container.NewHBox(layout.NewSpacer(), buttons),
-	)
-
-	card := widget.NewCard("", "", content)
-	card.Resize(fyne.NewSize(800, 0))
+	var feedbackButtons fyne.CanvasObject
+	if c.onFeedback != nil {
+		c.upButton = widget.NewButtonWithIcon("Полезно", theme.ConfirmIcon(), func() {
+			c.onFeedback(true)
+		})
+		c.upButton.Importance = widget.LowImportance
+		c.downButton = widget.NewButtonWithIcon("Не помогло", theme.CancelIcon(), func() {
+			c.onFeedback(false)
+		})
+		c.downButton.Importance = widget.LowImportance
+		feedbackButtons = container.NewHBox(c.upButton, c.downButton)
+	}
 
-	return widget.NewSimpleRenderer(card)
-}
+	content := container.NewVBox(questionLabel)
 
-// generateAnswer генерирует ответ с помощью Ollama
-func generateAnswer(question string, context string) (string, error) {
-	req := OllamaRequest{
-		Model:  "mistral", // Используем модель Mistral
-		Prompt: fmt.Sprintf("Вопрос: %s\nКонтекст: %s\nОтвет:", question, context),
-		Stream: false,
-		Options: map[string]any{
-			"temperature": 0.7,
-			"top_p":       0.9,
-			"num_predict": 2048, // Увеличиваем максимальную длину ответа
-		},
+	if c.matchInfo != "" {
+		content.Add(widget.NewLabelWithStyle(c.matchInfo, fyne.TextAlignLeading, fyne.TextStyle{Italic: true}))
 	}
-
-	jsonData, err := json.Marshal(req)
-	if err != nil {
-		return "", err
+	for _, fragment := range c.fragments {
+		fragmentText := widget.NewRichText(highlightSegments(fragment)...)
+		fragmentText.Wrapping = fyne.TextWrapWord
+		content.Add(fragmentText)
 	}
 
-	resp, err := http.Post("http://172.16.10.228:11434/api/generate", "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("ошибка подключения к Ollama: %v", err)
-	}
-	defer resp.Body.Close()
+	content.Add(answerLabel)
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
+	provenanceLabel := widget.NewLabelWithStyle(c.provenance, fyne.TextAlignLeading, fyne.TextStyle{Italic: true})
+	if c.provenance == "" {
+		provenanceLabel.Hide()
 	}
-
-	var ollamaResp OllamaResponse
-	if err := json.Unmarshal(body, &ollamaResp); err != nil {
-		return "", err
+	c.provenanceLabel = provenanceLabel
+	content.Add(provenanceLabel)
+
+	if len(c.sources) > 0 {
+		content.Add(widget.NewLabelWithStyle("Источники:", fyne.TextAlignLeading, fyne.TextStyle{Italic: true}))
+		for _, source := range c.sources {
+			sourceBtn := widget.NewButton(source.String(), func() {
+				if c.onSource != nil {
+					c.onSource(source)
+				}
+			})
+			sourceBtn.Importance = widget.LowImportance
+			sourceBtn.Alignment = widget.ButtonAlignLeading
+			content.Add(sourceBtn)
+		}
 	}
 
-	return ollamaResp.Response, nil
+	content.Add(container.NewBorder(nil, nil, feedbackButtons, buttons))
+
+	card := widget.NewCard("", "", content)
+	card.Resize(fyne.NewSize(800, 0))
+
+	return widget.NewSimpleRenderer(card)
 }
 
 // Добавляем структуру для формы
 type FAQForm struct {
 	question *widget.Entry
 	answer   *widget.Entry
+	category *widget.SelectEntry
+	tags     *widget.Entry
 }
 
 // Добавляем структуру для редактирования
 type EditDialog struct {
 	question *widget.Entry
 	answer   *widget.Entry
+	category *widget.SelectEntry
+	tags     *widget.Entry
 	id       int
 }
 
+// newCategoryEntry создает поле категории с выбором из существующих; новую можно ввести вручную
+func newCategoryEntry(faq *FAQService) *widget.SelectEntry {
+	entry := widget.NewSelectEntry(faq.Categories())
+	entry.SetPlaceHolder("Например: Сеть/VPN")
+	return entry
+}
+
+// newTagsEntry создает поле тегов через запятую
+func newTagsEntry() *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("Например: vpn, удаленка")
+	return entry
+}
+
+// entryMeta возвращает категорию и теги записи одной строкой
+func entryMeta(entry FAQEntry) string {
+	var parts []string
+	if entry.Category != "" {
+		parts = append(parts, "Категория: "+entry.Category)
+	}
+	if len(entry.Tags) > 0 {
+		parts = append(parts, "Теги: "+strings.Join(entry.Tags, ", "))
+	}
+	return strings.Join(parts, " · ")
+}
+
 // Функция для создания диалога редактирования
-func createEditDialog(db *sql.DB, w fyne.Window, id int, question, answer string, onUpdate func()) {
+func createEditDialog(faq *FAQService, w fyne.Window, entry FAQEntry) {
 	dlg := &EditDialog{
 		question: widget.NewMultiLineEntry(),
 		answer:   widget.NewMultiLineEntry(),
-		id:       id,
+		category: newCategoryEntry(faq),
+		tags:     newTagsEntry(),
+		id:       entry.ID,
 	}
 
-	dlg.question.SetText(question)
-	dlg.answer.SetText(answer)
+	dlg.question.SetText(entry.Question)
+	dlg.answer.SetText(entry.Answer)
+	dlg.category.SetText(entry.Category)
+	dlg.tags.SetText(strings.Join(entry.Tags, ", "))
 
 	dlg.question.SetMinRowsVisible(3)
 	dlg.answer.SetMinRowsVisible(10)
@@ -236,76 +428,199 @@ func createEditDialog(db *sql.DB, w fyne.Window, id int, question, answer string
 		dlg.question,
 		widget.NewLabelWithStyle("Ответ:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 		dlg.answer,
+		widget.NewLabelWithStyle("Категория:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		dlg.category,
+		widget.NewLabelWithStyle("Теги:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		dlg.tags,
 	)
 
+	var editDialog dialog.Dialog
 	updateButton := widget.NewButtonWithIcon("Сохранить", theme.DocumentSaveIcon(), func() {
-		_, err := db.Exec("UPDATE faq SET question = ?, answer = ? WHERE id = ?",
-			dlg.question.Text, dlg.answer.Text, dlg.id)
+		err := faq.Update(FAQEntry{
+			ID:       dlg.id,
+			Question: dlg.question.Text,
+			Answer:   dlg.answer.Text,
+			Category: dlg.category.Text,
+			Tags:     parseTags(dlg.tags.Text),
+		})
 		if err != nil {
 			dialog.ShowError(err, w)
 			return
 		}
-		onUpdate()
-		w.Close()
+		editDialog.Hide()
 	})
 	updateButton.Importance = widget.HighImportance
 
+	historyButton := widget.NewButtonWithIcon("История правок", theme.HistoryIcon(), func() {
+		showRevisions(faq, w, dlg.id)
+	})
+
 	content.Add(container.NewHBox(
 		layout.NewSpacer(),
+		historyButton,
 		updateButton,
 	))
 
 	scroll := container.NewScroll(content)
 	scroll.SetMinSize(fyne.NewSize(800, 600))
 
-	dialog.ShowCustom("Редактирование", "Закрыть", scroll, w)
+	editDialog = dialog.NewCustom("Редактирование", "Закрыть", scroll, w)
+	editDialog.Show()
+}
+
+// openFAQEntry открывает актуальную версию записи FAQ в диалоге редактирования
+func openFAQEntry(faq *FAQService, w fyne.Window, id int) {
+	entry, ok := faq.Get(id)
+	if !ok {
+		dialog.ShowInformation("Запись не найдена", fmt.Sprintf("Запись #%d удалена из базы", id), w)
+		return
+	}
+	createEditDialog(faq, w, entry)
+}
+
+// showRevisions показывает историю правок записи: что изменилось в каждой правке
+// и кнопки, возвращающие любую из прежних версий
+func showRevisions(faq *FAQService, w fyne.Window, id int) {
+	revisions, err := faq.Revisions(id)
+	if err != nil {
+		dialog.ShowError(err, w)
+		return
+	}
+	if len(revisions) == 0 {
+		dialog.ShowInformation("История правок", "Запись еще не редактировалась", w)
+		return
+	}
+
+	var revisionsDialog dialog.Dialog
+	restore := func(question, answer string) {
+		dialog.ShowConfirm("Подтверждение", "Восстановить эту версию записи?", func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := faq.Restore(id, question, answer); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			revisionsDialog.Hide()
+			dialog.ShowInformation("Успех", "Версия восстановлена", w)
+		}, w)
+	}
+
+	content := container.NewVBox()
+	for i, rev := range revisions {
+		title := rev.CreatedAt
+		if rev.Editor != "" {
+			title += " · " + rev.Editor
+		}
+		body := container.NewVBox()
+		if rev.OldQuestion != rev.NewQuestion {
+			body.Add(widget.NewLabelWithStyle("Вопрос:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+			body.Add(diffView(rev.OldQuestion, rev.NewQuestion))
+		}
+		if rev.OldAnswer != rev.NewAnswer {
+			body.Add(widget.NewLabelWithStyle("Ответ:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+			body.Add(diffView(rev.OldAnswer, rev.NewAnswer))
+		}
+
+		buttons := container.NewHBox(layout.NewSpacer())
+		// Текст до самой первой сохраненной правки тоже можно вернуть
+		if i == len(revisions)-1 && rev.OldQuestion != "" {
+			buttons.Add(widget.NewButtonWithIcon("Вернуть исходный текст", theme.ContentUndoIcon(), func() {
+				restore(rev.OldQuestion, rev.OldAnswer)
+			}))
+		}
+		if i > 0 {
+			restoreButton := widget.NewButtonWithIcon("Восстановить эту версию", theme.ContentUndoIcon(), func() {
+				restore(rev.NewQuestion, rev.NewAnswer)
+			})
+			restoreButton.Importance = widget.HighImportance
+			buttons.Add(restoreButton)
+		}
+		body.Add(buttons)
+
+		subtitle := "Изменение"
+		switch {
+		case i == 0:
+			subtitle = "Текущая версия"
+		case rev.OldQuestion == "" && rev.OldAnswer == "":
+			subtitle = "Создание записи"
+		}
+		content.Add(widget.NewCard(title, subtitle, body))
+	}
+
+	scroll := container.NewScroll(content)
+	scroll.SetMinSize(fyne.NewSize(800, 600))
+	revisionsDialog = dialog.NewCustom(fmt.Sprintf("История правок записи #%d", id), "Закрыть", scroll, w)
+	revisionsDialog.Show()
+}
+
+// diffView показывает построчное сравнение: удаленные строки красным, добавленные зеленым
+func diffView(oldText, newText string) *widget.RichText {
+	var segments []widget.RichTextSegment
+	for _, line := range diffLines(oldText, newText) {
+		prefix, colorName := "  ", theme.ColorNameForeground
+		switch line.Op {
+		case DiffInsert:
+			prefix, colorName = "+ ", theme.ColorNameSuccess
+		case DiffDelete:
+			prefix, colorName = "- ", theme.ColorNameError
+		}
+		segments = append(segments, &widget.TextSegment{
+			Text: prefix + line.Text,
+			Style: widget.RichTextStyle{
+				ColorName: colorName,
+				TextStyle: fyne.TextStyle{Monospace: true},
+			},
+		})
+	}
+	view := widget.NewRichText(segments...)
+	view.Wrapping = fyne.TextWrapWord
+	return view
 }
 
 // Обновляем функцию createFAQForm
-func createFAQForm(db *sql.DB, w fyne.Window) fyne.CanvasObject {
+func createFAQForm(db *sql.DB, faq *FAQService, favorites *FavoritesStore, backups *BackupService, w fyne.Window) fyne.CanvasObject {
 	form := &FAQForm{
 		question: widget.NewMultiLineEntry(),
 		answer:   widget.NewMultiLineEntry(),
+		category: newCategoryEntry(faq),
+		tags:     newTagsEntry(),
 	}
 
 	form.question.SetPlaceHolder("Введите вопрос")
 	form.answer.SetPlaceHolder("Введите ответ")
 
 	faqListContainer := container.NewVBox()
-	var updateFAQList func()
-	updateFAQList = func() {
+	updateFAQList := func() {
 		faqListContainer.Objects = nil
-		rows, err := db.Query("SELECT id, question, answer FROM faq ORDER BY id DESC")
-		if err != nil {
-			faqListContainer.Add(widget.NewLabel("Ошибка загрузки ответов"))
-			return
-		}
-		defer rows.Close()
-		for rows.Next() {
-			var id int
-			var question, answer string
-			if err := rows.Scan(&id, &question, &answer); err != nil {
-				continue
-			}
+		entries := faq.Entries()
+		for i := len(entries) - 1; i >= 0; i-- {
+			entry := entries[i]
 
-			questionLabel := widget.NewLabelWithStyle(question, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
-			answerLabel := widget.NewLabelWithStyle(answer, fyne.TextAlignLeading, fyne.TextStyle{})
+			questionLabel := widget.NewLabelWithStyle(entry.Question, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			answerLabel := widget.NewLabelWithStyle(entry.Answer, fyne.TextAlignLeading, fyne.TextStyle{})
 			answerLabel.Wrapping = fyne.TextWrapWord
+			metaLabel := widget.NewLabelWithStyle(entryMeta(entry), fyne.TextAlignLeading, fyne.TextStyle{Italic: true})
+			if metaLabel.Text == "" {
+				metaLabel.Hide()
+			}
 
 			editBtn := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), func() {
-				createEditDialog(db, w, id, question, answer, updateFAQList)
+				createEditDialog(faq, w, entry)
 			})
 			editBtn.Importance = widget.HighImportance
 
+			historyBtn := widget.NewButtonWithIcon("", theme.HistoryIcon(), func() {
+				showRevisions(faq, w, entry.ID)
+			})
+			historyBtn.Importance = widget.HighImportance
+
 			deleteBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
-				dialog.ShowConfirm("Подтверждение", "Удалить запись?", func(ok bool) {
+				dialog.ShowConfirm("Подтверждение", "Переместить запись в корзину?", func(ok bool) {
 					if ok {
-						_, err := db.Exec("DELETE FROM faq WHERE id = ?", id)
-						if err != nil {
+						if err := faq.Delete(entry.ID); err != nil {
 							dialog.ShowError(err, w)
-							return
 						}
-						updateFAQList()
 					}
 				}, w)
 			})
@@ -313,6 +628,7 @@ func createFAQForm(db *sql.DB, w fyne.Window) fyne.CanvasObject {
 
 			buttons := container.NewHBox(
 				layout.NewSpacer(),
+				historyBtn,
 				editBtn,
 				deleteBtn,
 			)
@@ -320,6 +636,7 @@ func createFAQForm(db *sql.DB, w fyne.Window) fyne.CanvasObject {
 			content := container.NewVBox(
 				questionLabel,
 				answerLabel,
+				metaLabel,
 				buttons,
 			)
 
@@ -327,17 +644,27 @@ func createFAQForm(db *sql.DB, w fyne.Window) fyne.CanvasObject {
 			faqListContainer.Add(card)
 		}
 		faqListContainer.Refresh()
+		form.category.SetOptions(faq.Categories())
 	}
 	updateFAQList()
 
+	// Список перерисовывается при любом изменении FAQ, в том числе из диалога на вкладке поиска
+	faq.OnChange(func() {
+		fyne.Do(updateFAQList)
+	})
+
 	addButton := widget.NewButtonWithIcon("", theme.ContentAddIcon(), func() {
 		if form.question.Text == "" || form.answer.Text == "" {
 			dialog.ShowInformation("Ошибка", "Заполните все поля", w)
 			return
 		}
 
-		_, err := db.Exec("INSERT INTO faq (question, answer) VALUES (?, ?)",
-			form.question.Text, form.answer.Text)
+		_, err := faq.Create(FAQEntry{
+			Question: form.question.Text,
+			Answer:   form.answer.Text,
+			Category: form.category.Text,
+			Tags:     parseTags(form.tags.Text),
+		})
 		if err != nil {
 			dialog.ShowError(err, w)
 			return
@@ -345,18 +672,38 @@ func createFAQForm(db *sql.DB, w fyne.Window) fyne.CanvasObject {
 
 		form.question.SetText("")
 		form.answer.SetText("")
+		form.tags.SetText("")
 		dialog.ShowInformation("Успех", "Ответ добавлен в базу", w)
-		updateFAQList()
 	})
 	addButton.Importance = widget.HighImportance
 	addButton.Resize(fyne.NewSize(40, 40))
 
+	importButton := widget.NewButtonWithIcon("Импорт из CSV/JSON", theme.DownloadIcon(), func() {
+		showImportDialog(faq, w)
+	})
+	exportButton := widget.NewButtonWithIcon("Экспорт", theme.UploadIcon(), func() {
+		showExportDialog(db, faq, favorites, w)
+	})
+	backupButton := widget.NewButtonWithIcon("Резервные копии", theme.StorageIcon(), func() {
+		showBackupsDialog(backups, w)
+	})
+
 	formContainer := container.NewVBox(
-		widget.NewLabelWithStyle("Добавить новый ответ", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewHBox(
+			widget.NewLabelWithStyle("Добавить новый ответ", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			layout.NewSpacer(),
+			importButton,
+			exportButton,
+			backupButton,
+		),
 		widget.NewLabel("Вопрос:"),
 		form.question,
 		widget.NewLabel("Ответ:"),
 		form.answer,
+		widget.NewLabel("Категория (уровни через «/»):"),
+		form.category,
+		widget.NewLabel("Теги через запятую:"),
+		form.tags,
 		container.NewHBox(layout.NewSpacer(), addButton),
 	)
 
@@ -374,43 +721,29 @@ func createFAQForm(db *sql.DB, w fyne.Window) fyne.CanvasObject {
 	)
 }
 
-// Добавляю структуру для истории
-type HistoryEntry struct {
-	ID       int
-	Question string
-	Answer   string
-	Date     string
-}
-
-// Добавляю функции для работы с историей
-func saveToHistory(db *sql.DB, question, answer string) error {
-	_, err := db.Exec("INSERT INTO history (question, answer) VALUES (?, ?)", question, answer)
-	return err
-}
-
-func loadHistory(db *sql.DB) ([]HistoryEntry, error) {
-	rows, err := db.Query("SELECT id, question, answer, date FROM history ORDER BY date DESC LIMIT 10")
+func main() {
+	cfg, args, err := loadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		return nil, err
+		log.Fatal(err)
 	}
-	defer rows.Close()
-
-	var history []HistoryEntry
-	for rows.Next() {
-		var entry HistoryEntry
-		if err := rows.Scan(&entry.ID, &entry.Question, &entry.Answer, &entry.Date); err != nil {
-			return nil, err
-		}
-		history = append(history, entry)
+	// Без команды запускается окно
+	if len(args) == 0 {
+		args = []string{"gui"}
+	}
+	if err := runCommand(cfg, args, os.Stdout); err != nil && !errors.Is(err, flag.ErrHelp) {
+		log.Fatal(err)
 	}
-	return history, nil
 }
 
-func main() {
+// runGUI открывает окно приложения и возвращает управление, когда его закроют
+func runGUI(cfg Config) error {
 	a := app.New()
 	w := a.NewWindow("Техподдержка НИТИ")
 
-	iconBytes, err := os.ReadFile("logo.png")
+	iconBytes, err := os.ReadFile(cfg.Paths.Icon)
 	if err != nil {
 		log.Printf("Ошибка загрузки иконки: %v", err)
 	} else {
@@ -422,7 +755,7 @@ func main() {
 	a.Settings().SetTheme(&NITITheme{theme.DefaultTheme()})
 
 	// Загружаем логотип
-	logo := canvas.NewImageFromFile("niti_logo_140x300.jpg")
+	logo := canvas.NewImageFromFile(cfg.Paths.Logo)
 	logo.SetMinSize(fyne.NewSize(200, 70))
 	logo.FillMode = canvas.ImageFillContain
 	logo.Resize(fyne.NewSize(200, 70))
@@ -430,77 +763,48 @@ func main() {
 	// Создаем контейнер для логотипа с отступами
 	logoContainer := container.NewPadded(logo)
 
-	// 1. Подключение к базе данных SQLite3
-	db, err := sql.Open("sqlite3", "faq.db")
+	// 1. Подключение к базе SQLite3 и индексу Bleve, сверка индекса с базой
+	services, err := openServices(cfg)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	defer db.Close()
-
-	// Создаем таблицу для избранных ответов
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS favorites (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			question TEXT,
-			answer TEXT,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)
-	`)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// Создаем таблицу для истории
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS history (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			question TEXT,
-			answer TEXT,
-			date DATETIME DEFAULT CURRENT_TIMESTAMP
-		)
-	`)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// 2. Загрузка всех вопросов и ответов из базы данных
-	faqEntries, err := loadFAQEntries(db)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// 3. Создание индекса Bleve
-	index, err := createBleveIndex(faqEntries)
-	if err != nil {
-		log.Fatal(err)
+	defer services.Close()
+	db, faq, feedback, favorites := services.DB, services.FAQ, services.Feedback, services.Favorites
+	searcher, assistant, ollama := services.Searcher, services.Assistant, services.Ollama
+
+	// Окончательно удаляем записи, которые пролежали в корзине дольше срока хранения
+	startTrashPurge(db, cfg.Trash.RetentionDays)
+
+	// Снимки базы по расписанию; индекс после восстановления строится заново
+	backups := NewBackupService(db, cfg.Paths.DB, cfg.Backup)
+	backups.Start()
+
+	// Эмбеддинги для векторного поиска считаются в фоне: Ollama может быть недоступна при старте.
+	// После изменений FAQ пересчитываются только затронутые записи
+	if cfg.Search.Mode != SearchModeBleve {
+		go services.syncVectors(context.Background())
+		faq.OnChange(func() {
+			go services.syncVectors(context.Background())
+		})
 	}
-	defer index.Close()
 
-	// Загружаем историю
-	history, err := loadHistory(db)
-	if err != nil {
-		log.Printf("Ошибка загрузки истории: %v", err)
-		history = make([]HistoryEntry, 0)
-	}
+	// Перечитывает вкладку "История"; задается при создании вкладки
+	var reloadHistory func()
 
-	// Создаем список истории
-	historyList := widget.NewList(
-		func() int { return len(history) },
-		func() fyne.CanvasObject {
-			return container.NewVBox(
-				widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
-				widget.NewLabel(""),
-			)
-		},
-		func(id widget.ListItemID, item fyne.CanvasObject) {
-			box := item.(*fyne.Container)
-			questionLabel := box.Objects[0].(*widget.Label)
-			answerLabel := box.Objects[1].(*widget.Label)
-
-			questionLabel.SetText(history[id].Question)
-			answerLabel.SetText(history[id].Answer)
-		},
-	)
+	// После восстановления из снимка перечитываем все, что держится в памяти
+	backups.OnRestore(func() {
+		n, err := faq.Rebuild()
+		if err != nil {
+			log.Printf("Ошибка перестроения индекса: %v", err)
+		} else {
+			log.Printf("База восстановлена из снимка, проиндексировано записей: %d", n)
+		}
+		if err := feedback.load(); err != nil {
+			log.Printf("Ошибка загрузки оценок: %v", err)
+		}
+		favorites.changed()
+		go reloadHistory()
+	})
 
 	// 4. Создание GUI элементы
 	title := canvas.NewText("Техническая поддержка НИТИ", theme.ForegroundColor())
@@ -529,6 +833,27 @@ func main() {
 	// Создаем контейнер для поля ввода с отступами и тенью
 	inputContainer := container.NewPadded(input)
 
+	// Фильтр по категории; список обновляется при изменении FAQ
+	const allCategories = "Все категории"
+	categorySelect := widget.NewSelect(append([]string{allCategories}, faq.Categories()...), nil)
+	categorySelect.SetSelected(allCategories)
+	faq.OnChange(func() {
+		fyne.Do(func() {
+			options := append([]string{allCategories}, faq.Categories()...)
+			categorySelect.SetOptions(options)
+			// Категорию могли переименовать или удалить: фильтр по ней ничего бы не нашел
+			if !slices.Contains(options, categorySelect.Selected) {
+				categorySelect.SetSelected(allCategories)
+			}
+		})
+	})
+	currentFilter := func() SearchFilter {
+		if categorySelect.Selected == allCategories {
+			return SearchFilter{}
+		}
+		return SearchFilter{Category: categorySelect.Selected}
+	}
+
 	// Создаем контейнер для результатов
 	resultsContainer := container.NewVBox()
 
@@ -548,134 +873,367 @@ func main() {
 	}
 
 	go func() {
-		resp, err := http.Get("http://172.16.10.228:11434/api/tags")
+		statusCode, err := ollama.checkStatus()
 		if err != nil {
 			updateOllamaStatus("Отключено", color.NRGBA{R: 255, G: 0, B: 0, A: 255})
 			return
 		}
-		defer resp.Body.Close()
-		if resp.StatusCode == http.StatusOK {
+		if statusCode == http.StatusOK {
 			updateOllamaStatus("Подключено", color.NRGBA{R: 0, G: 180, B: 0, A: 255})
 		} else {
 			updateOllamaStatus("Ошибка", color.NRGBA{R: 255, G: 165, B: 0, A: 255})
 		}
 	}()
 
-	// 5. Функция поиска ответа с использованием Bleve и Ollama
-	findAnswer := func(question string) {
-		if strings.TrimSpace(question) == "" {
-			dialog.ShowInformation("Предупреждение", "Пожалуйста, введите вопрос", w)
-			return
+	// Сохраняет готовый ответ в историю и обновляет вкладку "История".
+	// Возвращает идентификатор записи истории или 0, если сохранить не удалось
+	addToHistory := func(entry HistoryEntry) int {
+		historyID, err := saveToHistory(db, entry)
+		if err != nil {
+			log.Printf("Ошибка сохранения в историю: %v", err)
 		}
+		reloadHistory()
+		return historyID
+	}
+
+	// Создает карточку с ответом и действиями над ним; ref — запись, которую карточка добавит в избранное
+	newAnswerCard := func(question, answer string, ref FavoriteRef) *ResultCard {
+		var card *ResultCard
+		card = newResultCard(question, answer,
+			func(text string) {
+				w.Clipboard().SetContent(text)
+				card.used()
+				dialog.ShowInformation("Успех", "Ответ скопирован в буфер обмена", w)
+			},
+			func(question, answer string) {
+				err := favorites.Add(card.ref, question, answer)
+				if errors.Is(err, ErrFavoriteExists) {
+					dialog.ShowInformation("Избранное", "Этот ответ уже в избранном", w)
+					return
+				}
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				card.used()
+				dialog.ShowInformation("Успех", "Ответ добавлен в избранное", w)
+			},
+			func(question, answer string) {
+				err := favorites.RemoveRef(card.ref)
+				if errors.Is(err, ErrFavoriteNotFound) {
+					dialog.ShowInformation("Избранное", "Этого ответа нет в избранном", w)
+					return
+				}
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				dialog.ShowInformation("Успех", "Ответ удален из избранного", w)
+			},
+		)
+		card.setRef(ref)
+		card.setFeedback(func(helpful bool) {
+			title := "Ответ не помог"
+			if helpful {
+				title = "Ответ полезен"
+			}
+			comment := widget.NewMultiLineEntry()
+			comment.SetPlaceHolder("Необязательно")
+			dialog.ShowForm(title, "Оценить", "Отмена", []*widget.FormItem{
+				widget.NewFormItem("Комментарий", comment),
+			}, func(ok bool) {
+				if !ok {
+					return
+				}
+				err := feedback.Save(Feedback{
+					HistoryID: card.historyID,
+					FAQID:     card.ref.FAQID,
+					Helpful:   helpful,
+					Comment:   strings.TrimSpace(comment.Text),
+				})
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				card.markVote(helpful)
+				if helpful {
+					card.used()
+				}
+			}, w)
+		})
+		return card
+	}
+
+	// Отмена текущего поиска или генерации; доступ только из потока UI
+	var cancelSearch context.CancelFunc
+
+	stopButton := widget.NewButtonWithIcon("Стоп", theme.MediaStopIcon(), nil)
+	stopButton.Importance = widget.DangerImportance
+	stopButton.Disable()
+
+	stopSearch := func() {
+		if cancelSearch != nil {
+			cancelSearch()
+			cancelSearch = nil
+		}
+		progress.Hide()
+		stopButton.Disable()
+	}
+	stopButton.OnTapped = stopSearch
+
+	// runRequest выполняет fn в отдельной горутине с новым контекстом.
+	// Предыдущий запрос отменяется, чтобы его карточка не попала в результаты
+	runRequest := func(clearResults bool, fn func(ctx context.Context)) {
+		stopSearch()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancelSearch = cancel
 
 		// Показываем индикатор загрузки
-		fyne.Do(func() {
-			progress.Show()
+		progress.Show()
+		stopButton.Enable()
+		if clearResults {
 			resultsContainer.Objects = nil
 			resultsContainer.Refresh()
-		})
+		}
 
-		// Запускаем поиск в отдельной горутине
 		go func() {
-			// Сначала ищем точное совпадение в базе
-			var foundEntry FAQEntry
-			var found bool
-			for _, entry := range faqEntries {
-				if strings.EqualFold(strings.TrimSpace(entry.Question), strings.TrimSpace(question)) {
-					foundEntry = entry
-					found = true
-					break
+			defer fyne.Do(func() {
+				if ctx.Err() == nil {
+					stopButton.Disable()
+					progress.Hide()
 				}
+				cancel()
+			})
+			fn(ctx)
+		}()
+	}
+
+	// Выводит элементы, если поиск за это время не был отменен
+	addResults := func(ctx context.Context, objects ...fyne.CanvasObject) {
+		fyne.Do(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			for _, obj := range objects {
+				resultsContainer.Add(obj)
 			}
+			resultsContainer.Refresh()
+			progress.Hide()
+		})
+	}
 
-			var answer string
-			if found {
-				answer = foundEntry.Answer
-			} else {
-				// Если точное совпадение не найдено, ищем похожие вопросы
-				query := bleve.NewQueryStringQuery(question)
-				searchRequest := bleve.NewSearchRequest(query)
-				searchRequest.Size = 1
-				searchResult, err := index.Search(searchRequest)
+	// Показывает найденный в базе ответ и сохраняет его в историю.
+	// started — момент, когда оператор запустил поиск
+	showAnswer := func(ctx context.Context, question string, entry FAQEntry, started time.Time) {
+		if ctx.Err() != nil {
+			return
+		}
+		provenance := Provenance{Source: HistorySourceExact, FAQID: entry.ID, Latency: time.Since(started)}
+		historyID := addToHistory(HistoryEntry{Question: question, Answer: entry.Answer, Provenance: provenance})
+		card := newAnswerCard(question, entry.Answer, FavoriteRef{FAQID: entry.ID})
+		card.historyID = historyID
+		card.setProvenance(provenance.describe())
+		addResults(ctx, card)
+	}
 
-				if err != nil {
-					fyne.Do(func() {
-						progress.Hide()
-						dialog.ShowError(err, w)
-					})
+	// Сообщает об ошибке, если она не вызвана отменой запроса
+	showSearchError := func(ctx context.Context, err error) {
+		fyne.Do(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			progress.Hide()
+			dialog.ShowError(err, w)
+		})
+	}
+
+	// Генерирует ответ через Ollama, передавая найденные записи как контекст.
+	// historyID — запись истории, где вопрос уже сохранен вместе с найденными записями; 0 — записи нет
+	askModel := func(ctx context.Context, question string, hits []SearchHit, started time.Time, historyID int) {
+		prompt, err := assistant.Prompt(question, hits)
+		if err != nil {
+			showSearchError(ctx, err)
+			return
+		}
+
+		// Ответ выводится в карточку по мере поступления
+		sources := prompt.Sources()
+		card := newAnswerCard(question, "", FavoriteRef{})
+		card.setSources(sources, func(source Citation) {
+			openFAQEntry(faq, w, source.FAQID)
+		})
+		card.setPromote(func() {
+			if card.historyID == 0 {
+				dialog.ShowInformation("Добавление в базу", "Дождитесь, пока ответ будет готов", w)
+				return
+			}
+			// Категорию подсказываем по записи, на которую модель опиралась в первую очередь
+			draft := FAQEntry{Question: card.question, Answer: card.answer}
+			if len(prompt.Used) > 0 {
+				draft.Category = prompt.Used[0].Category
+			}
+			showPromoteDialog(db, faq, w, card.historyID, draft, func(FAQEntry) {
+				card.markPromoted()
+				go reloadHistory()
+			})
+		})
+		addResults(ctx, card)
+
+		answer, provenance, err := assistant.Generate(ctx, prompt, started, func(token string) {
+			fyne.Do(func() {
+				card.appendAnswer(token)
+			})
+		})
+		if err != nil {
+			showSearchError(ctx, err)
+			return
+		}
+		entry := HistoryEntry{Question: question, Answer: answer, Provenance: provenance, Sources: sources}
+		if historyID == 0 {
+			historyID = addToHistory(entry)
+		} else {
+			if err := replaceHistoryAnswer(db, historyID, entry); err != nil {
+				log.Printf("Ошибка сохранения в историю: %v", err)
+			}
+			reloadHistory()
+		}
+
+		// Сгенерированный ответ попадает в избранное как ссылка на запись истории
+		fyne.Do(func() {
+			card.setRef(FavoriteRef{HistoryID: historyID})
+			card.historyID = historyID
+			card.setProvenance(provenance.describe())
+		})
+	}
+
+	// Показывает список найденных записей с оценками и выделенными совпадениями.
+	// Модель вызывается, только если оператор решит, что ни одна запись не подходит.
+	// hits — все найденные записи: показываются первые cfg.Search.MaxResults, модели передаются все
+	showHits := func(ctx context.Context, question string, hits []SearchHit, started time.Time) {
+		if ctx.Err() != nil {
+			return
+		}
+		// Ответ в истории появится, когда оператор воспользуется одной из записей
+		historyID := addToHistory(HistoryEntry{
+			Question:   question,
+			Provenance: Provenance{Source: HistorySourceFAQ, Latency: time.Since(started)},
+		})
+
+		shown := hits[:min(len(hits), cfg.Search.MaxResults)]
+		objects := []fyne.CanvasObject{
+			widget.NewLabelWithStyle(fmt.Sprintf("Найдено записей: %d", len(shown)), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		}
+		for _, hit := range shown {
+			card := newAnswerCard(hit.Entry.Question, hit.Entry.Answer, FavoriteRef{FAQID: hit.Entry.ID})
+			// Оценка любой из найденных записей относится к этому же вопросу
+			card.historyID = historyID
+			card.setUse(func() {
+				if historyID == 0 {
 					return
 				}
-
-				// Если нашли похожий вопрос с достаточной релевантностью
-				if len(searchResult.Hits) > 0 && searchResult.Hits[0].Score > 0.3 {
-					for _, entry := range faqEntries {
-						if fmt.Sprintf("%d", entry.ID) == searchResult.Hits[0].ID {
-							foundEntry = entry
-							answer = foundEntry.Answer
-							break
-						}
-					}
-				} else {
-					// Если не нашли подходящего ответа, генерируем через Ollama
-					var err error
-					answer, err = generateAnswer(question, "")
-					if err != nil {
-						fyne.Do(func() {
-							progress.Hide()
-							dialog.ShowError(err, w)
-						})
-						return
-					}
+				if err := chooseHistoryAnswer(db, historyID, hit); err != nil {
+					log.Printf("Ошибка сохранения выбранного ответа в историю: %v", err)
+					return
 				}
+				go reloadHistory()
+			})
+			info := hit.describe()
+			if !hit.Confident {
+				info += " · низкая релевантность"
+			}
+			if meta := entryMeta(hit.Entry); meta != "" {
+				info += " · " + meta
+			}
+			if stats := feedback.Stats(hit.Entry.ID); stats != (FeedbackStats{}) {
+				info += " · " + stats.describe()
 			}
+			card.setMatch(info, hit.Fragments)
+			card.setProvenance(hitProvenance(HistorySourceFAQ, hit, started).describe())
+			objects = append(objects, card)
+		}
 
-			// Сохраняем в историю
-			if err := saveToHistory(db, question, answer); err != nil {
-				log.Printf("Ошибка сохранения в историю: %v", err)
+		var askButton *widget.Button
+		askButton = widget.NewButtonWithIcon("Ничего не подходит — спросить ИИ", theme.QuestionIcon(), func() {
+			askButton.Disable()
+			started := time.Now()
+			runRequest(false, func(ctx context.Context) {
+				askModel(ctx, question, hits, started, historyID)
+			})
+		})
+		askButton.Importance = widget.WarningImportance
+		objects = append(objects, container.NewHBox(layout.NewSpacer(), askButton))
+
+		addResults(ctx, objects...)
+	}
+
+	// 5. Функция поиска ответа с использованием Bleve и Ollama
+	var findAnswer func(question string)
+
+	// Показывает, сколько записей с совпадениями в каждой категории и с каждым тегом.
+	// Нажатие на категорию повторяет поиск с фильтром по ней
+	showFacets := func(ctx context.Context, question string) {
+		facets, err := searcher.Facets(ctx, question, 10)
+		if err != nil {
+			log.Printf("Ошибка подсчета по категориям: %v", err)
+			return
+		}
+		if len(facets.Categories) == 0 && len(facets.Tags) == 0 {
+			return
+		}
+
+		row := container.NewHBox()
+		if len(facets.Categories) > 0 {
+			row.Add(widget.NewLabelWithStyle("По категориям:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+			for _, facet := range facets.Categories {
+				category := facet.Value
+				button := widget.NewButton(fmt.Sprintf("%s (%d)", category, facet.Count), func() {
+					categorySelect.SetSelected(category)
+					findAnswer(question)
+				})
+				button.Importance = widget.LowImportance
+				row.Add(button)
 			}
+		}
+		if len(facets.Tags) > 0 {
+			tags := make([]string, 0, len(facets.Tags))
+			for _, facet := range facets.Tags {
+				tags = append(tags, fmt.Sprintf("%s (%d)", facet.Value, facet.Count))
+			}
+			row.Add(widget.NewLabelWithStyle("Теги: "+strings.Join(tags, ", "), fyne.TextAlignLeading, fyne.TextStyle{Italic: true}))
+		}
+		addResults(ctx, container.NewHScroll(row))
+	}
+
+	findAnswer = func(question string) {
+		if strings.TrimSpace(question) == "" {
+			dialog.ShowInformation("Предупреждение", "Пожалуйста, введите вопрос", w)
+			return
+		}
 
-			// Обновляем историю
-			history, err = loadHistory(db)
+		filter := currentFilter()
+		started := time.Now()
+		runRequest(true, func(ctx context.Context) {
+			// Сначала ищем точное совпадение в базе, затем похожие вопросы
+			lookup, err := assistant.Lookup(ctx, question, filter)
 			if err != nil {
-				log.Printf("Ошибка загрузки истории: %v", err)
+				showSearchError(ctx, err)
+				return
+			}
+			if lookup.Exact != nil {
+				showAnswer(ctx, question, *lookup.Exact, started)
+				return
 			}
-			fyne.Do(func() {
-				historyList.Refresh()
-			})
 
-			// Создаем карточку с ответом
-			card := newResultCard(question, answer,
-				func(text string) {
-					w.Clipboard().SetContent(text)
-					dialog.ShowInformation("Успех", "Ответ скопирован в буфер обмена", w)
-				},
-				func(question, answer string) {
-					_, err := db.Exec("INSERT INTO favorites (question, answer) VALUES (?, ?)",
-						question, answer)
-					if err != nil {
-						dialog.ShowError(err, w)
-						return
-					}
-					dialog.ShowInformation("Успех", "Ответ добавлен в избранное", w)
-				},
-				func(question, answer string) {
-					_, err := db.Exec("DELETE FROM favorites WHERE question = ? AND answer = ?", question, answer)
-					if err != nil {
-						dialog.ShowError(err, w)
-						return
-					}
-					mainTabs.Items[2].Content = loadFavorites(db, w)
-					mainTabs.Refresh()
-					dialog.ShowInformation("Успех", "Ответ удален из избранного", w)
-				},
-			)
+			// Похожие записи показываем списком: модель вызывается, только если ни одна не подойдет
+			showFacets(ctx, question)
+			if len(lookup.Hits) > 0 {
+				showHits(ctx, question, lookup.Hits, started)
+				return
+			}
 
-			fyne.Do(func() {
-				resultsContainer.Add(card)
-				resultsContainer.Refresh()
-				progress.Hide()
-			})
-		}()
+			// Если в базе ничего похожего нет, генерируем через Ollama
+			askModel(ctx, question, lookup.Hits, started, 0)
+		})
 	}
 
 	// Обновляем стиль кнопок
@@ -697,6 +1255,7 @@ func main() {
 		layout.NewSpacer(),
 		pasteButton,
 		searchButton,
+		stopButton,
 		layout.NewSpacer(),
 	)
 
@@ -716,22 +1275,56 @@ func main() {
 		})
 	}
 
+	// Корзина: восстановленная запись сразу появляется на своей вкладке.
+	// Записи FAQ обновляются сами через FAQService.OnChange
+	trashTab, refreshTrash := createTrashTab(db, faq, w, func(table string) {
+		switch table {
+		case TrashFavorites:
+			favorites.changed()
+		case TrashHistory:
+			go reloadHistory()
+		}
+	})
+
+	// История: повтор запроса переключает на вкладку поиска
+	historyTab, reload := createHistoryTab(db, faq, favorites, w, func(question string) {
+		input.SetText(question)
+		mainTabs.SelectIndex(0)
+		findAnswer(question)
+	})
+	reloadHistory = reload
+
+	gapsTab, refreshGaps := createGapsTab(db, faq, w)
+
 	// 6. Создание вкладок
 	mainTabs = container.NewAppTabs(
 		container.NewTabItem("Поиск", container.NewVBox(
 			headerContainer,
 			container.NewHBox(layout.NewSpacer(), searchLabel, layout.NewSpacer()),
 			inputContainer,
+			container.NewHBox(layout.NewSpacer(), widget.NewLabel("Категория:"), categorySelect, layout.NewSpacer()),
 			buttonsContainer,
 			progress,
 			ollamaStatus,
 			resultsContainer,
 		)),
-		container.NewTabItem("История", historyList),
-		container.NewTabItem("Избранное", loadFavorites(db, w)),
-		container.NewTabItem("Управление БД", createFAQForm(db, w)),
+		container.NewTabItem("История", historyTab),
+		container.NewTabItem("Избранное", createFavoritesTab(favorites, w)),
+		container.NewTabItem("Управление БД", createFAQForm(db, faq, favorites, backups, w)),
+		container.NewTabItem("Пробелы", gapsTab),
+		container.NewTabItem("Корзина", trashTab),
 	)
 
+	// Пробелы и корзина меняются с любой вкладки, поэтому перечитываем их при открытии
+	mainTabs.OnSelected = func(tab *container.TabItem) {
+		switch tab.Text {
+		case "Пробелы":
+			refreshGaps()
+		case "Корзина":
+			refreshTrash()
+		}
+	}
+
 	// Устанавливаем стиль вкладок
 	mainTabs.SetTabLocation(container.TabLocationTop)
 	mainTabs.Resize(fyne.NewSize(1200, 900))
@@ -743,86 +1336,5 @@ func main() {
 	w.Resize(fyne.NewSize(1200, 900))
 	w.CenterOnScreen()
 	w.ShowAndRun()
-}
-
-// loadFavorites загружает избранные ответы из базы данных
-func loadFavorites(db *sql.DB, w fyne.Window) fyne.CanvasObject {
-	rows, err := db.Query("SELECT question, answer FROM favorites ORDER BY created_at DESC")
-	if err != nil {
-		return container.NewVBox(widget.NewLabel("Ошибка загрузки избранного"))
-	}
-	defer rows.Close()
-
-	content := container.NewVBox()
-	content.Resize(fyne.NewSize(800, 600))
-	for rows.Next() {
-		var question, answer string
-		if err := rows.Scan(&question, &answer); err != nil {
-			continue
-		}
-		card := newResultCard(question, answer,
-			func(text string) {
-				w.Clipboard().SetContent(text)
-				dialog.ShowInformation("Успех", "Ответ скопирован в буфер обмена", w)
-			},
-			func(question, answer string) {
-				// Удаление из избранного
-			},
-			func(question, answer string) {
-				// Удаление из избранного
-				_, err := db.Exec("DELETE FROM favorites WHERE question = ? AND answer = ?", question, answer)
-				if err != nil {
-					dialog.ShowError(err, w)
-					return
-				}
-				// Обновляем вкладку избранного
-				mainTabs.Items[2].Content = loadFavorites(db, w)
-				mainTabs.Refresh()
-				dialog.ShowInformation("Успех", "Ответ удален из избранного", w)
-			})
-		content.Add(card)
-	}
-	scroll := container.NewScroll(content)
-	return scroll
-}
-
-// loadFAQEntries загружает все вопросы и ответы из базы данных
-func loadFAQEntries(db *sql.DB) ([]FAQEntry, error) {
-	rows, err := db.Query("SELECT id, question, answer FROM faq")
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var entries []FAQEntry
-	for rows.Next() {
-		var entry FAQEntry
-		if err := rows.Scan(&entry.ID, &entry.Question, &entry.Answer); err != nil {
-			return nil, err
-		}
-		entries = append(entries, entry)
-	}
-	return entries, nil
-}
-
-// createBleveIndex создает и заполняет индекс Bleve
-func createBleveIndex(entries []FAQEntry) (bleve.Index, error) {
-	mapping := bleve.NewIndexMapping()
-	index, err := bleve.New("faq.bleve", mapping)
-	if err != nil {
-		// Если индекс уже существует, открываем его
-		index, err = bleve.Open("faq.bleve")
-		if err != nil {
-			return nil, err
-		}
-		return index, nil
-	}
-
-	for _, entry := range entries {
-		if err := index.Index(fmt.Sprintf("%d", entry.ID), entry); err != nil {
-			return nil, err
-		}
-	}
-
-	return index, nil
+	return nil
 }
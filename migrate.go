@@ -0,0 +1,209 @@
+package main
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// migrationFiles — SQL-миграции вида NNNN_описание.sql, применяются по возрастанию номера
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration — одна миграция схемы
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// MigrationStatus — миграция и время ее применения, если она уже применена
+type MigrationStatus struct {
+	Migration
+	AppliedAt string
+}
+
+// Applied сообщает, применена ли миграция к базе
+func (s MigrationStatus) Applied() bool {
+	return s.AppliedAt != ""
+}
+
+// loadMigrations читает встроенные миграции и сортирует их по номеру
+func loadMigrations() ([]Migration, error) {
+	files, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(files))
+	seen := make(map[int]string)
+	for _, file := range files {
+		base := strings.TrimSuffix(path.Base(file), ".sql")
+		number, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("миграция %s: имя должно иметь вид NNNN_описание.sql", file)
+		}
+		version, err := strconv.Atoi(number)
+		if err != nil {
+			return nil, fmt.Errorf("миграция %s: неверный номер: %w", file, err)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("миграции %s и %s имеют одинаковый номер", other, file)
+		}
+		seen[version] = file
+
+		body, err := migrationFiles.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, SQL: string(body)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// ensureSchemaVersionTable создает таблицу учета примененных миграций
+func ensureSchemaVersionTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_version (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	return err
+}
+
+// migrationStatus возвращает все известные миграции с отметкой о применении.
+// База не меняется, поэтому годится для status и dry-run
+func migrationStatus(db *sql.DB) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[int]bool, len(migrations))
+	status := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = true
+		status = append(status, MigrationStatus{Migration: m, AppliedAt: applied[m.Version]})
+	}
+	for version := range applied {
+		if !known[version] {
+			return nil, fmt.Errorf("база содержит миграцию %04d, неизвестную этой версии программы", version)
+		}
+	}
+	return status, nil
+}
+
+// appliedMigrations возвращает номера примененных миграций и время их применения
+func appliedMigrations(db *sql.DB) (map[int]string, error) {
+	var exists int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_version'").Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int]string)
+	if exists == 0 {
+		return applied, nil
+	}
+
+	rows, err := db.Query("SELECT version, applied_at FROM schema_version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		var appliedAt string
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// pendingMigrations возвращает миграции, которые еще не применены к базе
+func pendingMigrations(db *sql.DB) ([]Migration, error) {
+	status, err := migrationStatus(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, s := range status {
+		if !s.Applied() {
+			pending = append(pending, s.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// runMigrations применяет недостающие миграции по порядку, каждую в своей транзакции.
+// Откат не поддерживается: схема только развивается вперед
+func runMigrations(db *sql.DB) ([]Migration, error) {
+	if err := ensureSchemaVersionTable(db); err != nil {
+		return nil, err
+	}
+	pending, err := pendingMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, m := range pending {
+		if err := applyMigration(db, m); err != nil {
+			return pending[:i], fmt.Errorf("миграция %04d_%s: %w", m.Version, m.Name, err)
+		}
+	}
+	return pending, nil
+}
+
+func applyMigration(db *sql.DB, m Migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.SQL); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO schema_version (version, name) VALUES (?, ?)", m.Version, m.Name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// openDatabase открывает базу SQLite и приводит ее схему к актуальной версии
+func openDatabase(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := runMigrations(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	for _, m := range applied {
+		log.Printf("Применена миграция %04d_%s", m.Version, m.Name)
+	}
+	return db, nil
+}
@@ -0,0 +1,38 @@
+-- Исходная схема. Все таблицы создаются с IF NOT EXISTS, чтобы миграция
+-- применялась и к существующим faq.db, где часть таблиц уже есть
+
+CREATE TABLE IF NOT EXISTS faq (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	question TEXT NOT NULL,
+	answer TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS favorites (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	question TEXT,
+	answer TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS history (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	question TEXT,
+	answer TEXT,
+	date DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS history_sources (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	history_id INTEGER NOT NULL REFERENCES history(id),
+	faq_id INTEGER NOT NULL,
+	question TEXT
+);
+
+CREATE TABLE IF NOT EXISTS faq_embeddings (
+	faq_id INTEGER NOT NULL,
+	model TEXT NOT NULL,
+	content_hash TEXT NOT NULL,
+	vector BLOB NOT NULL,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (faq_id, model)
+);
@@ -0,0 +1,19 @@
+-- Иерархические категории и свободные теги записей FAQ
+
+CREATE TABLE categories (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	parent_id INTEGER REFERENCES categories(id),
+	name TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX categories_parent_name ON categories (IFNULL(parent_id, 0), name);
+
+ALTER TABLE faq ADD COLUMN category_id INTEGER REFERENCES categories(id);
+
+CREATE TABLE faq_tags (
+	faq_id INTEGER NOT NULL REFERENCES faq(id),
+	tag TEXT NOT NULL,
+	PRIMARY KEY (faq_id, tag)
+);
+
+CREATE INDEX faq_tags_tag ON faq_tags (tag);
@@ -0,0 +1,14 @@
+-- История правок записей FAQ: текст до и после каждого изменения
+
+CREATE TABLE faq_revisions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	faq_id INTEGER NOT NULL,
+	old_question TEXT NOT NULL DEFAULT '',
+	old_answer TEXT NOT NULL DEFAULT '',
+	new_question TEXT NOT NULL,
+	new_answer TEXT NOT NULL,
+	editor TEXT NOT NULL DEFAULT '',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX faq_revisions_faq ON faq_revisions (faq_id, id);
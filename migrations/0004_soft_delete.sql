@@ -0,0 +1,5 @@
+-- Мягкое удаление: строка с заполненным deleted_at лежит в корзине
+
+ALTER TABLE faq ADD COLUMN deleted_at DATETIME;
+ALTER TABLE favorites ADD COLUMN deleted_at DATETIME;
+ALTER TABLE history ADD COLUMN deleted_at DATETIME;
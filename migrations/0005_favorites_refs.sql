@@ -0,0 +1,25 @@
+-- Избранное ссылается на запись FAQ или истории; question и answer остаются
+-- снимком текста на случай, если исходная запись удалена
+
+CREATE TABLE favorite_folders (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE favorites ADD COLUMN faq_id INTEGER REFERENCES faq(id);
+ALTER TABLE favorites ADD COLUMN history_id INTEGER REFERENCES history(id);
+ALTER TABLE favorites ADD COLUMN folder_id INTEGER REFERENCES favorite_folders(id);
+ALTER TABLE favorites ADD COLUMN note TEXT NOT NULL DEFAULT '';
+ALTER TABLE favorites ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+
+-- Старые записи привязываются к FAQ по совпадению текста
+UPDATE favorites SET faq_id = (
+	SELECT faq.id FROM faq
+	WHERE faq.question = favorites.question AND faq.answer = favorites.answer AND faq.deleted_at IS NULL
+	LIMIT 1
+);
+UPDATE favorites SET position = id;
+
+CREATE INDEX favorites_faq ON favorites (faq_id);
+CREATE INDEX favorites_history ON favorites (history_id);
@@ -0,0 +1,29 @@
+-- Источник ответа в истории и полнотекстовый поиск по прошлым вопросам и ответам.
+-- source: exact — точное совпадение, faq — найдено в базе, llm — ответ модели
+
+ALTER TABLE history ADD COLUMN source TEXT NOT NULL DEFAULT '';
+
+-- До появления колонки источники сохранялись только у ответов модели
+UPDATE history SET source = 'llm' WHERE id IN (SELECT history_id FROM history_sources);
+
+CREATE INDEX history_date ON history (date);
+
+CREATE VIRTUAL TABLE history_fts USING fts4(content="history", question, answer, tokenize=unicode61);
+
+CREATE TRIGGER history_fts_before_update BEFORE UPDATE ON history BEGIN
+	DELETE FROM history_fts WHERE docid = old.rowid;
+END;
+
+CREATE TRIGGER history_fts_before_delete BEFORE DELETE ON history BEGIN
+	DELETE FROM history_fts WHERE docid = old.rowid;
+END;
+
+CREATE TRIGGER history_fts_after_update AFTER UPDATE ON history BEGIN
+	INSERT INTO history_fts (docid, question, answer) VALUES (new.rowid, new.question, new.answer);
+END;
+
+CREATE TRIGGER history_fts_after_insert AFTER INSERT ON history BEGIN
+	INSERT INTO history_fts (docid, question, answer) VALUES (new.rowid, new.question, new.answer);
+END;
+
+INSERT INTO history_fts (history_fts) VALUES ('rebuild');
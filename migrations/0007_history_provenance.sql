@@ -0,0 +1,10 @@
+-- Происхождение ответа в истории: запись FAQ и ее оценка, модель с параметрами
+-- генерации и полное время ответа. У старых записей значения нулевые
+
+ALTER TABLE history ADD COLUMN faq_id INTEGER;
+ALTER TABLE history ADD COLUMN score REAL NOT NULL DEFAULT 0;
+ALTER TABLE history ADD COLUMN model TEXT NOT NULL DEFAULT '';
+ALTER TABLE history ADD COLUMN options TEXT NOT NULL DEFAULT '';
+ALTER TABLE history ADD COLUMN latency_ms INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX history_faq ON history (faq_id);
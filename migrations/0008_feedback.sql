@@ -0,0 +1,14 @@
+-- Оценки ответов операторами: полезен ли ответ и комментарий.
+-- Оценка привязана к записи истории и к записи FAQ, если ответ взят из базы
+
+CREATE TABLE feedback (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	history_id INTEGER,
+	faq_id INTEGER,
+	helpful INTEGER NOT NULL,
+	comment TEXT NOT NULL DEFAULT '',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX feedback_history ON feedback (history_id);
+CREATE INDEX feedback_faq ON feedback (faq_id);
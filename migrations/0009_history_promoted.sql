@@ -0,0 +1,3 @@
+-- Запись FAQ, созданная из ответа в истории
+
+ALTER TABLE history ADD COLUMN promoted_faq_id INTEGER;
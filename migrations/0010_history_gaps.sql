@@ -0,0 +1,4 @@
+-- Запись FAQ, написанная по группе вопросов, на которые в базе не было ответа.
+-- Такие вопросы больше не показываются в списке пробелов
+
+ALTER TABLE history ADD COLUMN resolved_faq_id INTEGER;
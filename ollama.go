@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// OllamaRequest представляет запрос к Ollama API
+type OllamaRequest struct {
+	Model   string         `json:"model"`
+	Prompt  string         `json:"prompt"`
+	Stream  bool           `json:"stream"`
+	Context string         `json:"context,omitempty"`
+	Options map[string]any `json:"options,omitempty"`
+}
+
+// OllamaResponse представляет ответ от Ollama API
+type OllamaResponse struct {
+	Response string `json:"response"`
+	Done     bool   `json:"done"`
+	Error    string `json:"error,omitempty"`
+}
+
+// EmbeddingRequest — запрос к /api/embeddings
+type EmbeddingRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+// EmbeddingResponse — вектор, возвращаемый /api/embeddings
+type EmbeddingResponse struct {
+	Embedding []float64 `json:"embedding"`
+}
+
+// OllamaClient обращается к Ollama с адресом, моделью и опциями из конфигурации
+type OllamaClient struct {
+	baseURL    string
+	model      string
+	embedModel string
+	options    map[string]any
+	httpClient *http.Client
+	statusHTTP *http.Client
+}
+
+// NewOllamaClient создает клиента Ollama по настройкам
+func NewOllamaClient(cfg OllamaConfig) *OllamaClient {
+	return &OllamaClient{
+		baseURL:    strings.TrimRight(cfg.URL, "/"),
+		model:      cfg.Model,
+		embedModel: cfg.EmbedModel,
+		options:    cfg.Options,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+		statusHTTP: &http.Client{Timeout: cfg.StatusTimeout},
+	}
+}
+
+// Model возвращает модель, которой генерируются ответы
+func (c *OllamaClient) Model() string {
+	return c.model
+}
+
+// OptionsJSON возвращает параметры генерации в JSON с ключами по алфавиту
+func (c *OllamaClient) OptionsJSON() string {
+	if len(c.options) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(c.options)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// generateAnswer генерирует ответ на готовый запрос с помощью Ollama в потоковом режиме.
+// Каждый полученный фрагмент передается в onToken, итоговый текст возвращается
+// только после того, как Ollama прислала done: true
+func (c *OllamaClient) generateAnswer(ctx context.Context, prompt string, onToken func(string)) (string, error) {
+	req := OllamaRequest{
+		Model:   c.model,
+		Prompt:  prompt,
+		Stream:  true,
+		Options: c.options,
+	}
+
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/generate", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("ошибка подключения к Ollama: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("Ollama вернула %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return readStream(resp.Body, onToken)
+}
+
+// readStream разбирает NDJSON-поток /api/generate: по одному JSON-объекту на строку
+func readStream(r io.Reader, onToken func(string)) (string, error) {
+	var answer strings.Builder
+	decoder := json.NewDecoder(r)
+	for {
+		var chunk OllamaResponse
+		if err := decoder.Decode(&chunk); err != nil {
+			if err == io.EOF {
+				return "", fmt.Errorf("поток Ollama оборвался до завершения ответа")
+			}
+			return "", fmt.Errorf("ошибка чтения потока Ollama: %w", err)
+		}
+		if chunk.Error != "" {
+			return "", fmt.Errorf("ошибка Ollama: %s", chunk.Error)
+		}
+		if chunk.Response != "" {
+			answer.WriteString(chunk.Response)
+			if onToken != nil {
+				onToken(chunk.Response)
+			}
+		}
+		if chunk.Done {
+			return answer.String(), nil
+		}
+	}
+}
+
+// embed возвращает вектор текста, посчитанный моделью эмбеддингов
+func (c *OllamaClient) embed(ctx context.Context, text string) ([]float64, error) {
+	jsonData, err := json.Marshal(EmbeddingRequest{Model: c.embedModel, Prompt: text})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/embeddings", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подключения к Ollama: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Ollama вернула %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var embResp EmbeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embResp); err != nil {
+		return nil, err
+	}
+	if len(embResp.Embedding) == 0 {
+		return nil, fmt.Errorf("модель %s вернула пустой вектор", c.embedModel)
+	}
+	return embResp.Embedding, nil
+}
+
+// checkStatus запрашивает список моделей, чтобы проверить доступность Ollama
+func (c *OllamaClient) checkStatus() (int, error) {
+	resp, err := c.statusHTTP.Get(c.baseURL + "/api/tags")
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// promoteToFAQ добавляет ответ из истории в базу знаний и связывает с ним запись истории
+func promoteToFAQ(db *sql.DB, faq *FAQService, historyID int, entry FAQEntry) (FAQEntry, error) {
+	created, err := faq.Create(entry)
+	if err != nil {
+		return FAQEntry{}, err
+	}
+	if historyID != 0 {
+		_, err := db.Exec("UPDATE history SET promoted_faq_id = ? WHERE id = ?", created.ID, historyID)
+		if err != nil {
+			return created, fmt.Errorf("запись FAQ #%d создана, но не связана с историей: %w", created.ID, err)
+		}
+	}
+	return created, nil
+}
+
+// showPromoteDialog открывает форму новой записи FAQ, заполненную ответом из истории
+func showPromoteDialog(db *sql.DB, faq *FAQService, w fyne.Window, historyID int, draft FAQEntry, onDone func(FAQEntry)) {
+	showDraftDialog(faq, w, "Проверка перед добавлением в базу", draft, func(entry FAQEntry) (FAQEntry, error) {
+		return promoteToFAQ(db, faq, historyID, entry)
+	}, onDone)
+}
+
+// showDraftDialog открывает заполненную форму новой записи FAQ.
+// Оператор может поправить вопрос, ответ, категорию и теги перед добавлением.
+// save создает запись; если вместе с ошибкой она вернула запись с ID, запись считается созданной.
+// onDone вызывается после добавления записи
+func showDraftDialog(faq *FAQService, w fyne.Window, title string, draft FAQEntry, save func(FAQEntry) (FAQEntry, error), onDone func(FAQEntry)) {
+	form := &FAQForm{
+		question: widget.NewMultiLineEntry(),
+		answer:   widget.NewMultiLineEntry(),
+		category: newCategoryEntry(faq),
+		tags:     newTagsEntry(),
+	}
+	form.question.SetText(draft.Question)
+	form.answer.SetText(draft.Answer)
+	form.category.SetText(draft.Category)
+	form.tags.SetText(strings.Join(draft.Tags, ", "))
+	form.question.SetMinRowsVisible(3)
+	form.answer.SetMinRowsVisible(10)
+
+	content := container.NewVBox(
+		widget.NewLabelWithStyle("Вопрос:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		form.question,
+		widget.NewLabelWithStyle("Ответ:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		form.answer,
+		widget.NewLabelWithStyle("Категория:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		form.category,
+		widget.NewLabelWithStyle("Теги:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		form.tags,
+	)
+
+	var draftDialog dialog.Dialog
+	create := func() {
+		entry, err := save(FAQEntry{
+			Question: form.question.Text,
+			Answer:   form.answer.Text,
+			Category: form.category.Text,
+			Tags:     parseTags(form.tags.Text),
+		})
+		if err != nil && entry.ID == 0 {
+			dialog.ShowError(err, w)
+			return
+		}
+		draftDialog.Hide()
+		if err != nil {
+			dialog.ShowError(err, w)
+		} else {
+			dialog.ShowInformation("Успех", fmt.Sprintf("Ответ добавлен в базу как запись #%d", entry.ID), w)
+		}
+		if onDone != nil {
+			onDone(entry)
+		}
+	}
+
+	addButton := widget.NewButtonWithIcon("Добавить в базу", theme.ContentAddIcon(), func() {
+		if strings.TrimSpace(form.question.Text) == "" || strings.TrimSpace(form.answer.Text) == "" {
+			dialog.ShowInformation("Ошибка", "Заполните вопрос и ответ", w)
+			return
+		}
+		// Такой вопрос уже есть — предупреждаем, но не запрещаем: ответы могут отличаться
+		if existing, ok := faq.FindExact(form.question.Text); ok {
+			dialog.ShowConfirm("Подтверждение",
+				fmt.Sprintf("В базе уже есть запись #%d с таким вопросом. Все равно добавить?", existing.ID),
+				func(ok bool) {
+					if ok {
+						create()
+					}
+				}, w)
+			return
+		}
+		create()
+	})
+	addButton.Importance = widget.HighImportance
+	content.Add(container.NewHBox(layout.NewSpacer(), addButton))
+
+	scroll := container.NewScroll(content)
+	scroll.SetMinSize(fyne.NewSize(800, 600))
+
+	draftDialog = dialog.NewCustom(title, "Отмена", scroll, w)
+	draftDialog.Show()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Provenance — откуда взят ответ и сколько времени ушло на запрос
+type Provenance struct {
+	Source  string        // HistorySourceExact, HistorySourceFAQ или HistorySourceLLM
+	FAQID   int           // запись FAQ, из которой взят ответ; 0 — ответ модели
+	Score   float64       // релевантность найденной записи; в режимах vector и hybrid — итоговая оценка поиска
+	Model   string        // модель, сгенерировавшая ответ
+	Options string        // параметры генерации в JSON
+	Latency time.Duration // от нажатия "Найти" до готового ответа
+}
+
+// describe возвращает происхождение ответа одной строкой
+func (p Provenance) describe() string {
+	var parts []string
+	if title := historySourceTitle(p.Source); title != "" {
+		parts = append(parts, title)
+	}
+	if p.FAQID != 0 {
+		parts = append(parts, fmt.Sprintf("FAQ #%d", p.FAQID))
+	}
+	if p.Score != 0 {
+		parts = append(parts, fmt.Sprintf("релевантность: %.3f", p.Score))
+	}
+	if p.Model != "" {
+		model := p.Model
+		if p.Options != "" {
+			model += " " + p.Options
+		}
+		parts = append(parts, model)
+	}
+	switch {
+	case p.Latency >= time.Second:
+		parts = append(parts, fmt.Sprintf("%.1f с", p.Latency.Seconds()))
+	case p.Latency > 0:
+		parts = append(parts, fmt.Sprintf("%d мс", p.Latency.Milliseconds()))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// hitProvenance описывает ответ, взятый из найденной записи FAQ
+func hitProvenance(source string, hit SearchHit, started time.Time) Provenance {
+	return Provenance{
+		Source:  source,
+		FAQID:   hit.Entry.ID,
+		Score:   hit.Score,
+		Latency: time.Since(started),
+	}
+}
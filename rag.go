@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// defaultPromptTemplate — шаблон запроса к модели; {{.Context}} заполняется записями FAQ
+const defaultPromptTemplate = `Ты — специалист технической поддержки НИТИ. Ответь на вопрос пользователя, опираясь на записи из базы знаний ниже.
+Если в них нет ответа, так и скажи и предложи общий порядок действий.
+
+База знаний:
+{{.Context}}
+
+Вопрос: {{.Question}}
+Ответ:`
+
+// charsPerToken — грубая оценка длины токена для русского текста.
+// Точный подсчет зависит от токенизатора модели, поэтому берем с запасом
+const charsPerToken = 3
+
+// PromptData — данные, доступные в шаблоне запроса
+type PromptData struct {
+	Question string
+	Context  string
+}
+
+// RAGBuilder собирает запрос к модели из вопроса и найденных записей FAQ
+type RAGBuilder struct {
+	tmpl      *template.Template
+	topK      int
+	maxTokens int
+}
+
+// NewRAGBuilder разбирает шаблон запроса из настроек
+func NewRAGBuilder(cfg RAGConfig) (*RAGBuilder, error) {
+	tmpl, err := template.New("prompt").Parse(cfg.PromptTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка в шаблоне rag.prompt_template: %w", err)
+	}
+	return &RAGBuilder{
+		tmpl:      tmpl,
+		topK:      cfg.TopK,
+		maxTokens: cfg.MaxContextTokens,
+	}, nil
+}
+
+// TopK возвращает, сколько записей FAQ запрашивать у поиска
+func (b *RAGBuilder) TopK() int {
+	return b.topK
+}
+
+// buildPrompt подставляет вопрос и контекст из записей FAQ в шаблон.
+// Возвращает также записи, которые реально попали в контекст
+func (b *RAGBuilder) buildPrompt(question string, entries []FAQEntry) (string, []FAQEntry, error) {
+	context, used := b.buildContext(entries)
+
+	var sb strings.Builder
+	data := PromptData{
+		Question: question,
+		Context:  context,
+	}
+	if err := b.tmpl.Execute(&sb, data); err != nil {
+		return "", nil, fmt.Errorf("ошибка подготовки запроса: %w", err)
+	}
+	return sb.String(), used, nil
+}
+
+// buildContext собирает блок контекста из записей в порядке релевантности,
+// пока он помещается в бюджет токенов. Первая запись при необходимости обрезается,
+// остальные добавляются только целиком
+func (b *RAGBuilder) buildContext(entries []FAQEntry) (string, []FAQEntry) {
+	if len(entries) > b.topK {
+		entries = entries[:b.topK]
+	}
+
+	var sb strings.Builder
+	var used []FAQEntry
+	budget := b.maxTokens
+	for i, entry := range entries {
+		block := fmt.Sprintf("[%d] Вопрос: %s\nОтвет: %s\n\n", entry.ID, strings.TrimSpace(entry.Question), strings.TrimSpace(entry.Answer))
+		cost := estimateTokens(block)
+		if b.maxTokens > 0 && cost > budget {
+			if i == 0 {
+				sb.WriteString(truncateRunes(block, budget*charsPerToken))
+				used = append(used, entry)
+			}
+			break
+		}
+		sb.WriteString(block)
+		used = append(used, entry)
+		budget -= cost
+	}
+	return strings.TrimSpace(sb.String()), used
+}
+
+// estimateTokens оценивает число токенов в тексте
+func estimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + charsPerToken - 1) / charsPerToken
+}
+
+// truncateRunes обрезает строку до n символов, не разрывая многобайтовые символы
+func truncateRunes(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"database/sql"
+)
+
+// Revision — одна правка записи FAQ: текст до и после изменения
+type Revision struct {
+	ID          int
+	FAQID       int
+	OldQuestion string // пусто у правки, которой запись была создана
+	OldAnswer   string
+	NewQuestion string
+	NewAnswer   string
+	Editor      string
+	CreatedAt   string
+}
+
+// saveRevision записывает правку, если текст записи действительно изменился
+func saveRevision(tx *sql.Tx, old, entry FAQEntry, editor string) error {
+	if old.Question == entry.Question && old.Answer == entry.Answer {
+		return nil
+	}
+	_, err := tx.Exec(`
+		INSERT INTO faq_revisions (faq_id, old_question, old_answer, new_question, new_answer, editor)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, entry.ID, old.Question, old.Answer, entry.Question, entry.Answer, editor)
+	return err
+}
+
+// Revisions возвращает правки записи, начиная с последней
+func (s *FAQService) Revisions(faqID int) ([]Revision, error) {
+	rows, err := s.db.Query(`
+		SELECT id, faq_id, old_question, old_answer, new_question, new_answer, editor, created_at
+		FROM faq_revisions
+		WHERE faq_id = ?
+		ORDER BY id DESC
+	`, faqID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []Revision
+	for rows.Next() {
+		var r Revision
+		err := rows.Scan(&r.ID, &r.FAQID, &r.OldQuestion, &r.OldAnswer, &r.NewQuestion, &r.NewAnswer, &r.Editor, &r.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, r)
+	}
+	return revisions, rows.Err()
+}
+
+// Restore возвращает записи текст вопроса и ответа. Категория и теги не меняются.
+// Восстановление проходит как обычная правка: запись переиндексируется,
+// а в истории появляется новая правка, которую тоже можно откатить
+func (s *FAQService) Restore(faqID int, question, answer string) error {
+	entry, ok := s.Get(faqID)
+	if !ok {
+		return ErrFAQNotFound
+	}
+	entry.Question = question
+	entry.Answer = answer
+	return s.Update(entry)
+}
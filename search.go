@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+// SearchHit — найденная запись FAQ с оценками релевантности
+type SearchHit struct {
+	Entry      FAQEntry
+	Score      float64 // итоговая оценка, по которой отсортированы результаты
+	BleveScore float64
+	Similarity float64
+	FromBleve  bool
+	FromVector bool
+	Confident  bool // оценка прошла пороги; остальные записи помечаются как малорелевантные
+	// Fragments — фрагменты вопроса и ответа, где Bleve нашел слова запроса (<mark>...</mark>)
+	Fragments []string
+}
+
+// describe возвращает оценки записи в читаемом виде
+func (h SearchHit) describe() string {
+	parts := []string{fmt.Sprintf("Релевантность: %.3f", h.Score)}
+	if h.FromBleve && h.FromVector {
+		parts = append(parts,
+			fmt.Sprintf("Bleve: %.3f", h.BleveScore),
+			fmt.Sprintf("сходство: %.3f", h.Similarity))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// ConfidencePolicy решает, достаточно ли надежна найденная запись
+type ConfidencePolicy struct {
+	MinBleveScore float64 `toml:"min_bleve_score"`
+	MinSimilarity float64 `toml:"min_similarity"`
+	// RequireBoth требует, чтобы запись нашли и Bleve, и векторный поиск (для режима hybrid)
+	RequireBoth bool `toml:"require_both"`
+}
+
+// Accept проверяет оценки записи по порогам политики.
+// bothRan — выполнялись ли оба поиска; только тогда применяется RequireBoth
+func (p ConfidencePolicy) Accept(hit SearchHit, bothRan bool) bool {
+	bleveOK := hit.FromBleve && hit.BleveScore > p.MinBleveScore
+	vectorOK := hit.FromVector && hit.Similarity >= p.MinSimilarity
+	if p.RequireBoth && bothRan {
+		return bleveOK && vectorOK
+	}
+	return bleveOK || vectorOK
+}
+
+// SearchFilter ограничивает поиск частью базы
+type SearchFilter struct {
+	Category string // путь категории; записи подкатегорий тоже подходят
+}
+
+// match проверяет запись по фильтру
+func (f SearchFilter) match(entry FAQEntry) bool {
+	return inCategory(entry.Category, f.Category)
+}
+
+// FacetCount — значение фасета и число подходящих под запрос записей с ним
+type FacetCount struct {
+	Value string
+	Count int
+}
+
+// Facets — количество найденных записей по категориям и тегам
+type Facets struct {
+	Categories []FacetCount
+	Tags       []FacetCount
+}
+
+// Searcher ищет записи FAQ, похожие на вопрос, в режиме из настроек
+type Searcher struct {
+	index    bleve.Index
+	vectors  *VectorStore
+	faq      *FAQService
+	feedback *FeedbackStore
+	cfg      SearchConfig
+}
+
+// NewSearcher создает поиск по индексу Bleve и векторному хранилищу.
+// Оценки операторов из feedback понижают записи, которыми чаще недовольны
+func NewSearcher(index bleve.Index, vectors *VectorStore, faq *FAQService, feedback *FeedbackStore, cfg SearchConfig) *Searcher {
+	return &Searcher{
+		index:    index,
+		vectors:  vectors,
+		faq:      faq,
+		feedback: feedback,
+		cfg:      cfg,
+	}
+}
+
+// Search возвращает до k записей, подходящих под фильтр, по убыванию релевантности.
+// Если векторный поиск недоступен, используется только Bleve
+func (s *Searcher) Search(ctx context.Context, question string, k int, filter SearchFilter) ([]SearchHit, error) {
+	// Пониженные по оценкам записи уступают место следующим, поэтому записей берется с запасом:
+	// каждая пониженная запись вытесняет из первых k не больше одной
+	fetch := k
+	if s.feedback != nil && s.cfg.FeedbackWeight != 0 {
+		fetch += s.feedback.DemotedCount()
+	}
+
+	var hits []SearchHit
+	var bothRan bool
+	var err error
+	switch s.cfg.Mode {
+	case SearchModeVector:
+		hits, err = s.searchVector(ctx, question, fetch, filter)
+		if err != nil && s.vectorFallback(ctx, err) {
+			hits, err = s.searchBleve(ctx, question, fetch, filter)
+		}
+	case SearchModeHybrid:
+		hits, bothRan, err = s.searchHybrid(ctx, question, fetch, filter)
+	default:
+		hits, err = s.searchBleve(ctx, question, fetch, filter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range hits {
+		hits[i].Confident = s.cfg.Confidence.Accept(hits[i], bothRan)
+	}
+	s.applyFeedback(hits)
+	if len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits, nil
+}
+
+// applyFeedback снижает оценку записей, которыми операторы чаще недовольны, и пересортировывает результаты.
+// Такие записи не считаются надежными, даже если прошли пороги
+func (s *Searcher) applyFeedback(hits []SearchHit) {
+	if s.feedback == nil || s.cfg.FeedbackWeight == 0 {
+		return
+	}
+	for i := range hits {
+		if !s.feedback.Demoted(hits[i].Entry.ID) {
+			continue
+		}
+		hits[i].Score *= s.feedback.penalty(hits[i].Entry.ID, s.cfg.FeedbackWeight)
+		hits[i].Confident = false
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+}
+
+// Facets считает записи, в которых встречаются слова вопроса, по категориям и тегам.
+// Фильтр не применяется: счетчики показывают, где еще есть совпадения
+func (s *Searcher) Facets(ctx context.Context, question string, size int) (Facets, error) {
+	searchRequest := bleve.NewSearchRequest(buildQuery(question, s.cfg))
+	searchRequest.Size = 0
+	searchRequest.AddFacet("Category", bleve.NewFacetRequest("Category", size))
+	searchRequest.AddFacet("Tags", bleve.NewFacetRequest("Tags", size))
+	searchResult, err := s.index.SearchInContext(ctx, searchRequest)
+	if err != nil {
+		return Facets{}, err
+	}
+
+	counts := func(name string) []FacetCount {
+		facet, ok := searchResult.Facets[name]
+		if !ok {
+			return nil
+		}
+		var out []FacetCount
+		for _, term := range facet.Terms.Terms() {
+			out = append(out, FacetCount{Value: term.Term, Count: term.Count})
+		}
+		return out
+	}
+	return Facets{Categories: counts("Category"), Tags: counts("Tags")}, nil
+}
+
+// vectorFallback сообщает, можно ли продолжить без векторного поиска
+func (s *Searcher) vectorFallback(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if !errors.Is(err, errNoVectors) {
+		log.Printf("Ошибка векторного поиска, используется Bleve: %v", err)
+	}
+	return true
+}
+
+// searchHybrid параллельно выполняет оба поиска и объединяет результаты.
+// Если векторный поиск недоступен, возвращает результаты Bleve и bothRan = false
+func (s *Searcher) searchHybrid(ctx context.Context, question string, k int, filter SearchFilter) ([]SearchHit, bool, error) {
+	// Берем кандидатов с запасом, чтобы запись из хвоста одного списка могла подняться за счет другого
+	pool := k * 3
+
+	var bleveHits, vectorHits []SearchHit
+	var bleveErr, vectorErr error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		bleveHits, bleveErr = s.searchBleve(ctx, question, pool, filter)
+	}()
+	go func() {
+		defer wg.Done()
+		vectorHits, vectorErr = s.searchVector(ctx, question, pool, filter)
+	}()
+	wg.Wait()
+
+	if bleveErr != nil {
+		return nil, false, bleveErr
+	}
+	bothRan := true
+	if vectorErr != nil {
+		if !s.vectorFallback(ctx, vectorErr) {
+			return nil, false, vectorErr
+		}
+		vectorHits = nil
+		bothRan = false
+	}
+
+	var fused []SearchHit
+	if s.cfg.Fusion == FusionWeighted {
+		fused = fuseWeighted(bleveHits, vectorHits, s.cfg.BleveWeight, s.cfg.VectorWeight)
+	} else {
+		fused = fuseRRF(bleveHits, vectorHits, s.cfg.RRFK)
+	}
+	if len(fused) > k {
+		fused = fused[:k]
+	}
+	return fused, bothRan, nil
+}
+
+// fuseRRF объединяет списки методом reciprocal rank fusion: сумма 1/(k + позиция)
+func fuseRRF(bleveHits, vectorHits []SearchHit, rrfK float64) []SearchHit {
+	merged := mergeHits(bleveHits, vectorHits)
+	for rank, hit := range bleveHits {
+		merged[hit.Entry.ID].Score += 1 / (rrfK + float64(rank+1))
+	}
+	for rank, hit := range vectorHits {
+		merged[hit.Entry.ID].Score += 1 / (rrfK + float64(rank+1))
+	}
+	return sortHits(merged)
+}
+
+// fuseWeighted складывает оценки с весами; оценки Bleve нормируются на лучшую,
+// потому что в отличие от косинусного сходства они не ограничены сверху
+func fuseWeighted(bleveHits, vectorHits []SearchHit, bleveWeight, vectorWeight float64) []SearchHit {
+	merged := mergeHits(bleveHits, vectorHits)
+	maxBleve := 0.0
+	for _, hit := range bleveHits {
+		maxBleve = math.Max(maxBleve, hit.BleveScore)
+	}
+	for _, hit := range merged {
+		if hit.FromBleve && maxBleve > 0 {
+			hit.Score += bleveWeight * hit.BleveScore / maxBleve
+		}
+		if hit.FromVector {
+			hit.Score += vectorWeight * hit.Similarity
+		}
+	}
+	return sortHits(merged)
+}
+
+// mergeHits сводит результаты обоих поисков в одну запись на каждый вопрос FAQ
+func mergeHits(bleveHits, vectorHits []SearchHit) map[int]*SearchHit {
+	merged := make(map[int]*SearchHit, len(bleveHits)+len(vectorHits))
+	for _, hit := range bleveHits {
+		merged[hit.Entry.ID] = &SearchHit{Entry: hit.Entry, BleveScore: hit.BleveScore, FromBleve: true, Fragments: hit.Fragments}
+	}
+	for _, hit := range vectorHits {
+		m, ok := merged[hit.Entry.ID]
+		if !ok {
+			m = &SearchHit{Entry: hit.Entry}
+			merged[hit.Entry.ID] = m
+		}
+		m.Similarity = hit.Similarity
+		m.FromVector = true
+	}
+	return merged
+}
+
+func sortHits(merged map[int]*SearchHit) []SearchHit {
+	hits := make([]SearchHit, 0, len(merged))
+	for _, hit := range merged {
+		hits = append(hits, *hit)
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Entry.ID < hits[j].Entry.ID
+	})
+	return hits
+}
+
+func (s *Searcher) searchBleve(ctx context.Context, question string, k int, filter SearchFilter) ([]SearchHit, error) {
+	searchRequest := bleve.NewSearchRequest(filterQuery(buildQuery(question, s.cfg), filter))
+	searchRequest.Size = k
+	searchRequest.Highlight = bleve.NewHighlight()
+	searchRequest.Highlight.AddField("Question")
+	searchRequest.Highlight.AddField("Answer")
+	searchResult, err := s.index.SearchInContext(ctx, searchRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]SearchHit, 0, len(searchResult.Hits))
+	for _, hit := range searchResult.Hits {
+		id, err := strconv.Atoi(hit.ID)
+		if err != nil {
+			continue
+		}
+		if entry, ok := s.faq.Get(id); ok {
+			hits = append(hits, SearchHit{
+				Entry:      entry,
+				Score:      hit.Score,
+				BleveScore: hit.Score,
+				FromBleve:  true,
+				Fragments:  append(hit.Fragments["Question"], hit.Fragments["Answer"]...),
+			})
+		}
+	}
+	return hits, nil
+}
+
+func (s *Searcher) searchVector(ctx context.Context, question string, k int, filter SearchFilter) ([]SearchHit, error) {
+	vectorHits, err := s.vectors.Search(ctx, question, k, func(id int) bool {
+		entry, ok := s.faq.Get(id)
+		return ok && filter.match(entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]SearchHit, 0, len(vectorHits))
+	for _, hit := range vectorHits {
+		if entry, ok := s.faq.Get(hit.FAQID); ok {
+			hits = append(hits, SearchHit{
+				Entry:      entry,
+				Score:      hit.Similarity,
+				Similarity: hit.Similarity,
+				FromVector: true,
+			})
+		}
+	}
+	return hits, nil
+}
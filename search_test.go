@@ -0,0 +1,127 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func bleveHit(id int, score float64) SearchHit {
+	return SearchHit{Entry: FAQEntry{ID: id}, BleveScore: score, FromBleve: true}
+}
+
+func vectorHit(id int, similarity float64) SearchHit {
+	return SearchHit{Entry: FAQEntry{ID: id}, Similarity: similarity, FromVector: true}
+}
+
+// checkFused сравнивает порядок и оценки объединенных результатов
+func checkFused(t *testing.T, hits []SearchHit, ids []int, scores []float64) {
+	t.Helper()
+	if len(hits) != len(ids) {
+		t.Fatalf("результатов %d, ожидалось %d", len(hits), len(ids))
+	}
+	for i, hit := range hits {
+		if hit.Entry.ID != ids[i] || math.Abs(hit.Score-scores[i]) > 1e-9 {
+			t.Errorf("позиция %d: запись %d с оценкой %.6f, ожидалась запись %d с оценкой %.6f",
+				i, hit.Entry.ID, hit.Score, ids[i], scores[i])
+		}
+	}
+}
+
+func TestFuseRRF(t *testing.T) {
+	tests := []struct {
+		name   string
+		bleve  []SearchHit
+		vector []SearchHit
+		ids    []int
+		scores []float64
+	}{
+		{
+			name:   "запись из обоих списков выше",
+			bleve:  []SearchHit{bleveHit(1, 3), bleveHit(2, 1)},
+			vector: []SearchHit{vectorHit(2, 0.9), vectorHit(3, 0.5)},
+			ids:    []int{2, 1, 3},
+			scores: []float64{1.0/61 + 1.0/62, 1.0 / 61, 1.0 / 62},
+		},
+		{
+			name:   "равные оценки по возрастанию идентификатора",
+			bleve:  []SearchHit{bleveHit(5, 3)},
+			vector: []SearchHit{vectorHit(3, 0.9)},
+			ids:    []int{3, 5},
+			scores: []float64{1.0 / 61, 1.0 / 61},
+		},
+		{
+			name:   "только Bleve",
+			bleve:  []SearchHit{bleveHit(2, 3), bleveHit(1, 1)},
+			ids:    []int{2, 1},
+			scores: []float64{1.0 / 61, 1.0 / 62},
+		},
+		{
+			name: "пустые списки",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkFused(t, fuseRRF(tt.bleve, tt.vector, 60), tt.ids, tt.scores)
+		})
+	}
+}
+
+func TestFuseWeighted(t *testing.T) {
+	tests := []struct {
+		name   string
+		bleve  []SearchHit
+		vector []SearchHit
+		ids    []int
+		scores []float64
+	}{
+		{
+			name:   "оценки Bleve нормируются на лучшую, равные по возрастанию идентификатора",
+			bleve:  []SearchHit{bleveHit(1, 4), bleveHit(2, 2)},
+			vector: []SearchHit{vectorHit(3, 1), vectorHit(2, 0.8)},
+			ids:    []int{2, 1, 3},
+			scores: []float64{0.65, 0.5, 0.5},
+		},
+		{
+			name:   "нулевые оценки Bleve",
+			bleve:  []SearchHit{bleveHit(1, 0)},
+			vector: []SearchHit{vectorHit(2, 0.2)},
+			ids:    []int{2, 1},
+			scores: []float64{0.1, 0},
+		},
+		{
+			name:   "только векторы с равным сходством",
+			vector: []SearchHit{vectorHit(4, 0.5), vectorHit(2, 0.5)},
+			ids:    []int{2, 4},
+			scores: []float64{0.25, 0.25},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkFused(t, fuseWeighted(tt.bleve, tt.vector, 0.5, 0.5), tt.ids, tt.scores)
+		})
+	}
+}
+
+func TestMergeHitsSources(t *testing.T) {
+	merged := mergeHits(
+		[]SearchHit{bleveHit(1, 2), bleveHit(2, 1)},
+		[]SearchHit{vectorHit(2, 0.7), vectorHit(3, 0.4)},
+	)
+	tests := []struct {
+		id         int
+		fromBleve  bool
+		fromVector bool
+	}{
+		{1, true, false},
+		{2, true, true},
+		{3, false, true},
+	}
+	for _, tt := range tests {
+		hit := merged[tt.id]
+		if hit == nil || hit.FromBleve != tt.fromBleve || hit.FromVector != tt.fromVector {
+			t.Errorf("запись %d: %+v, ожидалось FromBleve=%v FromVector=%v", tt.id, hit, tt.fromBleve, tt.fromVector)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// siteFiles — шаблоны страниц и статические файлы сайта базы знаний
+//
+//go:embed site/*
+var siteFiles embed.FS
+
+// siteEntriesDir — каталог страниц записей внутри сайта
+const siteEntriesDir = "entries"
+
+// siteEntry — запись FAQ на странице сайта
+type siteEntry struct {
+	FAQEntry
+	URL            string // относительно корня сайта
+	CategoryTitle  string
+	CategoryAnchor string
+}
+
+// siteCategory — раздел оглавления с записями одной категории
+type siteCategory struct {
+	Title   string
+	Anchor  string
+	Entries []siteEntry
+}
+
+// sitePage — данные для шаблона страницы
+type sitePage struct {
+	SiteTitle  string
+	Title      string
+	Root       string // путь от страницы к корню сайта
+	Generated  string
+	Categories []siteCategory
+	Entry      siteEntry
+}
+
+// siteSearchEntry — запись поискового индекса search.json
+type siteSearchEntry struct {
+	ID       int      `json:"id"`
+	Question string   `json:"question"`
+	Answer   string   `json:"answer"`
+	Category string   `json:"category"`
+	Tags     []string `json:"tags"`
+	URL      string   `json:"url"`
+}
+
+// generateSite собирает статический сайт базы знаний в каталоге dir:
+// index.html с оглавлением по категориям и поиском, по странице на запись в entries/
+// и поисковый индекс search.json. Страницы удаленных записей убираются; другие файлы
+// в каталоге не трогаются. Возвращает число страниц записей
+func generateSite(dir, title string, entries []FAQEntry) (int, error) {
+	tmpl, err := template.ParseFS(siteFiles, "site/templates.html")
+	if err != nil {
+		return 0, fmt.Errorf("ошибка разбора шаблонов сайта: %w", err)
+	}
+
+	entriesDir := filepath.Join(dir, siteEntriesDir)
+	if err := os.MkdirAll(entriesDir, 0o755); err != nil {
+		return 0, err
+	}
+
+	categories := siteCategories(entries)
+	generated := time.Now().Format("02.01.2006 15:04")
+	page := func(pageTitle, root string) sitePage {
+		return sitePage{SiteTitle: title, Title: pageTitle, Root: root, Generated: generated}
+	}
+	render := func(path, name string, data sitePage) error {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := tmpl.ExecuteTemplate(file, name, data); err != nil {
+			file.Close()
+			return fmt.Errorf("ошибка создания %s: %w", path, err)
+		}
+		return file.Close()
+	}
+
+	// Пустой массив, а не null: поиск на странице перебирает записи индекса
+	search := []siteSearchEntry{}
+	pages := make(map[string]bool)
+	written := 0
+	for _, category := range categories {
+		for _, entry := range category.Entries {
+			data := page(entry.Question+" — "+title, "../")
+			data.Entry = entry
+			if err := render(filepath.Join(dir, filepath.FromSlash(entry.URL)), "entry", data); err != nil {
+				return written, err
+			}
+			written++
+			pages[filepath.Base(entry.URL)] = true
+
+			tags := entry.Tags
+			if tags == nil {
+				tags = []string{}
+			}
+			search = append(search, siteSearchEntry{
+				ID:       entry.ID,
+				Question: entry.Question,
+				Answer:   entry.Answer,
+				Category: entry.Category,
+				Tags:     tags,
+				URL:      entry.URL,
+			})
+		}
+	}
+
+	if err := removeStalePages(entriesDir, pages); err != nil {
+		return written, err
+	}
+
+	index := page(title, "")
+	index.Categories = categories
+	if err := render(filepath.Join(dir, "index.html"), "index", index); err != nil {
+		return written, err
+	}
+
+	data, err := json.Marshal(search)
+	if err != nil {
+		return written, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "search.json"), data, 0o644); err != nil {
+		return written, err
+	}
+
+	for _, name := range []string{"style.css", "search.js"} {
+		static, err := fs.ReadFile(siteFiles, "site/"+name)
+		if err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), static, 0o644); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// removeStalePages удаляет из каталога страниц записей страницы, которых нет в pages.
+// Удаляются только файлы вида <id>.html, которые пишет generateSite: чужие файлы остаются
+func removeStalePages(dir string, pages map[string]bool) error {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || pages[name] || filepath.Ext(name) != ".html" {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimSuffix(name, ".html")); err != nil {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// siteCategories группирует записи по категориям в алфавитном порядке;
+// записи без категории идут последним разделом
+func siteCategories(entries []FAQEntry) []siteCategory {
+	byCategory := make(map[string][]FAQEntry)
+	for _, entry := range entries {
+		byCategory[entry.Category] = append(byCategory[entry.Category], entry)
+	}
+	paths := make([]string, 0, len(byCategory))
+	for path := range byCategory {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	if _, ok := byCategory[""]; ok {
+		paths = append(paths, "")
+	}
+
+	categories := make([]siteCategory, 0, len(paths))
+	for i, path := range paths {
+		category := siteCategory{
+			Title:  strings.ReplaceAll(path, categorySeparator, " / "),
+			Anchor: fmt.Sprintf("category-%d", i+1),
+		}
+		if path == "" {
+			category.Title = "Без категории"
+		}
+		group := byCategory[path]
+		sort.Slice(group, func(i, j int) bool {
+			return strings.ToLower(group[i].Question) < strings.ToLower(group[j].Question)
+		})
+		for _, entry := range group {
+			category.Entries = append(category.Entries, siteEntry{
+				FAQEntry:       entry,
+				URL:            fmt.Sprintf("%s/%d.html", siteEntriesDir, entry.ID),
+				CategoryTitle:  category.Title,
+				CategoryAnchor: category.Anchor,
+			})
+		}
+		categories = append(categories, category)
+	}
+	return categories
+}
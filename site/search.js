@@ -0,0 +1,59 @@
+// Поиск по базе знаний в браузере: search.json загружается при первом вводе,
+// запись подходит, если в ее вопросе, ответе, категории или тегах есть начало каждого слова запроса
+(function () {
+	const input = document.getElementById("search");
+	const results = document.getElementById("results");
+	const categories = document.getElementById("categories");
+	const maxResults = 20;
+	let index = null;
+
+	function words(text) {
+		return text.toLowerCase().split(/[^\p{L}\p{N}]+/u).filter(Boolean);
+	}
+
+	function matches(entry, query) {
+		return query.every(q => entry.words.some(w => w.startsWith(q)));
+	}
+
+	function render(query) {
+		results.replaceChildren();
+		const found = index.filter(entry => matches(entry, query)).slice(0, maxResults);
+		if (found.length === 0) {
+			const li = document.createElement("li");
+			li.textContent = "Ничего не найдено";
+			results.append(li);
+		}
+		for (const entry of found) {
+			const li = document.createElement("li");
+			const a = document.createElement("a");
+			a.href = entry.url;
+			a.textContent = entry.question;
+			const snippet = document.createElement("span");
+			snippet.className = "snippet";
+			snippet.textContent = [entry.category, entry.answer.slice(0, 160)].filter(Boolean).join(" · ");
+			li.append(a, snippet);
+			results.append(li);
+		}
+	}
+
+	async function search() {
+		const query = words(input.value);
+		if (query.length === 0) {
+			results.hidden = true;
+			categories.hidden = false;
+			return;
+		}
+		if (index === null) {
+			const response = await fetch("search.json");
+			index = await response.json();
+			for (const entry of index) {
+				entry.words = words([entry.question, entry.answer, entry.category, entry.tags.join(" ")].join(" "));
+			}
+		}
+		render(query);
+		results.hidden = false;
+		categories.hidden = true;
+	}
+
+	input.addEventListener("input", search);
+})();
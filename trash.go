@@ -0,0 +1,282 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Таблицы с мягким удалением
+const (
+	TrashFAQ       = "faq"
+	TrashFavorites = "favorites"
+	TrashHistory   = "history"
+)
+
+// trashKinds — таблицы корзины в порядке вывода и их названия для оператора
+var trashKinds = []struct {
+	table string
+	title string
+}{
+	{TrashFAQ, "FAQ"},
+	{TrashFavorites, "Избранное"},
+	{TrashHistory, "История"},
+}
+
+// trashDependents — связанные строки, которые стираются вместе с записью.
+// Внешние ключи в соединениях не включены, поэтому каскадного удаления нет и связи чистятся здесь.
+// %s заменяется подзапросом с идентификаторами стираемых записей
+var trashDependents = map[string][]string{
+	TrashFAQ: {
+		"DELETE FROM faq_tags WHERE faq_id IN (%s)",
+		"DELETE FROM faq_revisions WHERE faq_id IN (%s)",
+		"DELETE FROM faq_embeddings WHERE faq_id IN (%s)",
+		"DELETE FROM feedback WHERE faq_id IN (%s)",
+	},
+	TrashHistory: {
+		"DELETE FROM history_sources WHERE history_id IN (%s)",
+		// Оценки записей FAQ продолжают влиять на поиск и без вопроса, на который они даны
+		"DELETE FROM feedback WHERE faq_id IS NULL AND history_id IN (%s)",
+		"UPDATE feedback SET history_id = NULL WHERE history_id IN (%s)",
+	},
+}
+
+// TrashItem — удаленная запись FAQ, избранного или истории
+type TrashItem struct {
+	Table     string
+	ID        int
+	Question  string
+	Answer    string
+	DeletedAt string
+}
+
+// kindTitle возвращает название раздела, из которого удалена запись
+func (t TrashItem) kindTitle() string {
+	for _, kind := range trashKinds {
+		if kind.table == t.Table {
+			return kind.title
+		}
+	}
+	return t.Table
+}
+
+// loadTrash загружает содержимое корзины, начиная с последних удаленных
+func loadTrash(db *sql.DB) ([]TrashItem, error) {
+	var items []TrashItem
+	for _, kind := range trashKinds {
+		rows, err := db.Query(fmt.Sprintf(
+			"SELECT id, IFNULL(question, ''), IFNULL(answer, ''), deleted_at FROM %s WHERE deleted_at IS NOT NULL", kind.table))
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			item := TrashItem{Table: kind.table}
+			if err := rows.Scan(&item.ID, &item.Question, &item.Answer, &item.DeletedAt); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// deleted_at во всех таблицах записан CURRENT_TIMESTAMP, поэтому строки сравнимы
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt > items[j].DeletedAt
+	})
+	return items, nil
+}
+
+// trashRow переносит строку избранного или истории в корзину.
+// Записи FAQ удаляются через FAQService.Delete, чтобы обновился индекс
+func trashRow(db *sql.DB, table string, id int) error {
+	_, err := db.Exec(fmt.Sprintf("UPDATE %s SET deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL", table), id)
+	return err
+}
+
+// restoreTrashItem возвращает запись из корзины
+func restoreTrashItem(db *sql.DB, faq *FAQService, item TrashItem) error {
+	if item.Table == TrashFAQ {
+		return faq.Undelete(item.ID)
+	}
+	_, err := db.Exec(fmt.Sprintf("UPDATE %s SET deleted_at = NULL WHERE id = ?", item.Table), item.ID)
+	return err
+}
+
+// deleteTrashItem окончательно стирает запись из корзины
+func deleteTrashItem(db *sql.DB, item TrashItem) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := eraseTrashed(tx, item.Table, "id = ?", item.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// purgeTrash стирает записи, пролежавшие в корзине дольше retentionDays дней.
+// Возвращает число стертых записей
+func purgeTrash(db *sql.DB, retentionDays int) (int64, error) {
+	return eraseAll(db, "deleted_at < datetime('now', ?)", fmt.Sprintf("-%d days", retentionDays))
+}
+
+// emptyTrash стирает все содержимое корзины
+func emptyTrash(db *sql.DB) (int64, error) {
+	return eraseAll(db, "1 = 1")
+}
+
+func eraseAll(db *sql.DB, where string, args ...any) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var total int64
+	for _, kind := range trashKinds {
+		n, err := eraseTrashed(tx, kind.table, where, args...)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, tx.Commit()
+}
+
+// eraseTrashed стирает из таблицы удаленные записи, подходящие под условие, и связанные с ними строки
+func eraseTrashed(tx *sql.Tx, table, where string, args ...any) (int64, error) {
+	ids := fmt.Sprintf("SELECT id FROM %s WHERE deleted_at IS NOT NULL AND %s", table, where)
+	for _, query := range trashDependents[table] {
+		if _, err := tx.Exec(fmt.Sprintf(query, ids), args...); err != nil {
+			return 0, err
+		}
+	}
+
+	res, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE deleted_at IS NOT NULL AND %s", table, where), args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// startTrashPurge стирает устаревшие записи из корзины при запуске и затем раз в час
+func startTrashPurge(db *sql.DB, retentionDays int) {
+	if retentionDays == 0 {
+		return
+	}
+	purge := func() {
+		n, err := purgeTrash(db, retentionDays)
+		if err != nil {
+			log.Printf("Ошибка очистки корзины: %v", err)
+			return
+		}
+		if n > 0 {
+			log.Printf("Из корзины окончательно удалено записей: %d", n)
+		}
+	}
+	purge()
+	go func() {
+		for range time.Tick(time.Hour) {
+			purge()
+		}
+	}()
+}
+
+// createTrashTab создает вкладку "Корзина". onRestore вызывается после восстановления записи
+// с именем ее таблицы, чтобы вкладка, откуда она была удалена, обновилась.
+// Возвращает также функцию, перечитывающую корзину
+func createTrashTab(db *sql.DB, faq *FAQService, w fyne.Window, onRestore func(table string)) (fyne.CanvasObject, func()) {
+	list := container.NewVBox()
+
+	var refresh func()
+	refresh = func() {
+		list.Objects = nil
+		items, err := loadTrash(db)
+		if err != nil {
+			list.Add(widget.NewLabel("Ошибка загрузки корзины: " + err.Error()))
+			list.Refresh()
+			return
+		}
+		if len(items) == 0 {
+			list.Add(widget.NewLabel("Корзина пуста"))
+		}
+		for _, item := range items {
+			answerLabel := widget.NewLabel(item.Answer)
+			answerLabel.Wrapping = fyne.TextWrapWord
+
+			restoreBtn := widget.NewButtonWithIcon("Восстановить", theme.ContentUndoIcon(), func() {
+				if err := restoreTrashItem(db, faq, item); err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				refresh()
+				onRestore(item.Table)
+			})
+			restoreBtn.Importance = widget.HighImportance
+
+			deleteBtn := widget.NewButtonWithIcon("Удалить навсегда", theme.DeleteIcon(), func() {
+				dialog.ShowConfirm("Подтверждение", "Удалить запись без возможности восстановления?", func(ok bool) {
+					if !ok {
+						return
+					}
+					if err := deleteTrashItem(db, item); err != nil {
+						dialog.ShowError(err, w)
+						return
+					}
+					refresh()
+				}, w)
+			})
+			deleteBtn.Importance = widget.DangerImportance
+
+			content := container.NewVBox(
+				answerLabel,
+				container.NewHBox(layout.NewSpacer(), restoreBtn, deleteBtn),
+			)
+			list.Add(widget.NewCard(item.Question, fmt.Sprintf("%s · удалено %s", item.kindTitle(), item.DeletedAt), content))
+		}
+		list.Refresh()
+	}
+	refresh()
+
+	emptyBtn := widget.NewButtonWithIcon("Очистить корзину", theme.DeleteIcon(), func() {
+		dialog.ShowConfirm("Подтверждение", "Удалить все записи из корзины без возможности восстановления?", func(ok bool) {
+			if !ok {
+				return
+			}
+			if _, err := emptyTrash(db); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			refresh()
+		}, w)
+	})
+	emptyBtn.Importance = widget.DangerImportance
+
+	scroll := container.NewScroll(list)
+	scroll.SetMinSize(fyne.NewSize(800, 600))
+	return container.NewBorder(
+		container.NewHBox(
+			widget.NewLabelWithStyle("Удаленные записи", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			layout.NewSpacer(),
+			emptyBtn,
+		),
+		nil, nil, nil,
+		scroll,
+	), refresh
+}